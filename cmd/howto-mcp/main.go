@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -12,25 +13,35 @@ import (
 var version = "dev"
 
 func main() {
-	if err := run(); err != nil {
+	if err := run(os.Args[1:]); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 }
 
-func run() error {
-	globalDir, err := app.GlobalConfigDir()
-	if err != nil {
-		return fmt.Errorf("failed to resolve global config directory: %w", err)
+func run(args []string) error {
+	var overrides app.PathOverrides
+	var strict bool
+
+	fs := flag.NewFlagSet("howto-mcp", flag.ContinueOnError)
+	fs.BoolVar(&strict, "strict", false, "refuse to serve playbook names that are ambiguous within a library")
+	app.RegisterPathFlags(fs, &overrides)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
 	}
 
-	projectDir, err := app.ProjectConfigDir()
+	paths, err := app.ResolvePaths(overrides)
 	if err != nil {
-		return fmt.Errorf("failed to resolve project config directory: %w", err)
+		return err
 	}
 
-	loader := app.NewCachedRegistryLoader(globalDir, projectDir)
 	logger := log.New(os.Stderr, "howto-mcp: ", log.LstdFlags)
+	loader := app.NewCachedRegistryLoader(paths)
+	loader.SetLogger(logger)
+	loader.SetStrict(strict)
 
 	server := mcp.NewServer(os.Stdin, os.Stdout, loader, version, logger)
 	return server.Serve()
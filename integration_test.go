@@ -12,6 +12,7 @@ import (
 
 	"github.com/yourusername/howto/internal/app"
 	"github.com/yourusername/howto/internal/config"
+	"github.com/yourusername/howto/internal/instructions"
 	"github.com/yourusername/howto/internal/loader"
 	"github.com/yourusername/howto/internal/mcp"
 	"github.com/yourusername/howto/internal/output"
@@ -53,7 +54,7 @@ func TestIntegration_FullWorkflow(t *testing.T) {
 
 	// Test 2: Help output should list all playbooks
 	var helpBuf bytes.Buffer
-	output.PrintHelp(&helpBuf, reg)
+	output.PrintHelp(&helpBuf, registry.NewSnapshot(reg), instructions.LLMBullets())
 	helpOutput := helpBuf.String()
 
 	if !strings.Contains(helpOutput, "  rust-lang:") {
@@ -68,7 +69,7 @@ func TestIntegration_FullWorkflow(t *testing.T) {
 
 	// Test 3: Playbook output should show content only
 	var cmdBuf bytes.Buffer
-	if err := output.PrintPlaybook(&cmdBuf, reg, "rust-lang"); err != nil {
+	if err := output.PrintPlaybook(&cmdBuf, registry.NewSnapshot(reg), "rust-lang"); err != nil {
 		t.Fatalf("failed to print rust-lang playbook: %v", err)
 	}
 
@@ -128,7 +129,7 @@ func TestIntegration_UnknownPlaybook(t *testing.T) {
 	reg := registry.BuildRegistry(nil, nil, &config.ProjectConfig{})
 
 	var buf bytes.Buffer
-	err := output.PrintPlaybook(&buf, reg, "nonexistent")
+	err := output.PrintPlaybook(&buf, registry.NewSnapshot(reg), "nonexistent")
 	if err == nil {
 		t.Fatal("expected error for unknown playbook")
 	}
@@ -142,7 +143,7 @@ func TestIntegration_MCPServer(t *testing.T) {
 	globalPath := filepath.Join("testdata", ".config", "howto")
 	projectPath := filepath.Join("testdata", "project", ".howto")
 
-	loader := app.NewCachedRegistryLoader(globalPath, projectPath)
+	loader := app.NewCachedRegistryLoader(app.Paths{GlobalDir: globalPath, ProjectDir: projectPath})
 
 	input := strings.Join([]string{
 		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"clientInfo":{"name":"integration-test"}}}`,
@@ -167,8 +168,8 @@ func TestIntegration_MCPServer(t *testing.T) {
 		t.Fatalf("tools/list returned error: %+v", responses[1].Error)
 	}
 	tools, ok := responses[1].Result["tools"].([]any)
-	if !ok || len(tools) != 2 {
-		t.Fatalf("expected 2 tools, got %#v", responses[1].Result["tools"])
+	if !ok || len(tools) != 3 {
+		t.Fatalf("expected 3 tools, got %#v", responses[1].Result["tools"])
 	}
 
 	assertContentContains(t, responses[2].Result, "rust-lang")
@@ -217,6 +218,39 @@ func TestProjectConfigDir(t *testing.T) {
 	}
 }
 
+func TestResolvePaths(t *testing.T) {
+	t.Setenv("HOME", "/home/testuser")
+	t.Setenv("XDG_STATE_HOME", "")
+	t.Setenv(app.EnvGlobalDir, "/env/global")
+	t.Setenv(app.EnvProjectDir, "/env/repo")
+
+	paths, err := app.ResolvePaths(app.PathOverrides{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if paths.GlobalDir != "/env/global" {
+		t.Errorf("expected global dir from environment, got %q", paths.GlobalDir)
+	}
+	if paths.ProjectDir != filepath.Join("/env/repo", ".howto") {
+		t.Errorf("expected project library inside the environment project root, got %q", paths.ProjectDir)
+	}
+	if paths.StateDir != filepath.Join("/home/testuser", ".local", "state", "howto") {
+		t.Errorf("unexpected state dir %q", paths.StateDir)
+	}
+
+	paths, err = app.ResolvePaths(app.PathOverrides{
+		GlobalDir:  "/flag/global",
+		ProjectDir: "/flag/repo/.howto",
+		ConfigFile: "/flag/howto.yaml",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if paths.GlobalDir != "/flag/global" || paths.ProjectDir != "/flag/repo/.howto" || paths.ConfigFile != "/flag/howto.yaml" {
+		t.Errorf("expected flags to take precedence, got %+v", paths)
+	}
+}
+
 func TestRunVersion(t *testing.T) {
 	originalArgs := os.Args
 	defer func() { os.Args = originalArgs }()
@@ -254,6 +288,46 @@ func TestRunVersion(t *testing.T) {
 	}
 }
 
+func TestParseArgs(t *testing.T) {
+	opts, args, err := parseArgs([]string{"go-lang", "--profile", "release"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if opts.profile != "release" {
+		t.Errorf("expected profile release, got %q", opts.profile)
+	}
+	if len(args) != 1 || args[0] != "go-lang" {
+		t.Errorf("expected positional go-lang, got %v", args)
+	}
+
+	if _, _, err := parseArgs([]string{"--unknown"}); err == nil {
+		t.Error("expected error for unknown flag")
+	}
+
+	opts, args, err = parseArgs([]string{"--strict", "--", "search", "--version"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !opts.strict || opts.showVersion || opts.namesFrom != 0 {
+		t.Errorf("expected flags before -- only, got %+v", opts)
+	}
+	if len(args) != 2 || args[0] != "search" || args[1] != "--version" {
+		t.Errorf("expected everything after -- as playbook names, got %v", args)
+	}
+}
+
+func TestSubcommandCollisions(t *testing.T) {
+	reg := registry.Registry{
+		"search":  {Name: "search", FilePath: ".howto/search.md"},
+		"go-lang": {Name: "go-lang"},
+	}
+
+	diagnostics := subcommandCollisions(registry.NewSnapshot(reg))
+	if len(diagnostics) != 1 || diagnostics[0].Name != "search" || !strings.Contains(diagnostics[0].Message, "howto -- search") {
+		t.Fatalf("expected one collision for the search playbook, got %+v", diagnostics)
+	}
+}
+
 type mcpResponse struct {
 	ID     any            `json:"id"`
 	Result map[string]any `json:"result"`
@@ -1,9 +1,11 @@
 package app
 
 import (
+	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // GlobalConfigDir returns the global configuration directory path.
@@ -32,3 +34,106 @@ func ProjectConfigDir() (string, error) {
 func ProjectConfigDirFrom(cwd string) string {
 	return filepath.Join(cwd, ".howto")
 }
+
+// StateDir returns the directory where howto keeps machine-local state such as trust decisions.
+// Default: $XDG_STATE_HOME/howto/ or ~/.local/state/howto/
+func StateDir() (string, error) {
+	if stateHome := os.Getenv("XDG_STATE_HOME"); stateHome != "" {
+		return filepath.Join(stateHome, "howto"), nil
+	}
+
+	home := os.Getenv("HOME")
+	if home == "" {
+		return "", fmt.Errorf("HOME environment variable not set")
+	}
+
+	return filepath.Join(home, ".local", "state", "howto"), nil
+}
+
+// Environment variables that override library locations.
+const (
+	EnvGlobalDir  = "HOWTO_GLOBAL_DIR"
+	EnvProjectDir = "HOWTO_PROJECT_DIR"
+)
+
+// Paths locates the libraries and state used to build the registry.
+type Paths struct {
+	GlobalDir  string // Global playbook library
+	ProjectDir string // Project playbook library (.howto)
+	ConfigFile string // Project config; defaults to config.yaml inside ProjectDir when empty
+	StateDir   string // Trust decisions; trust checks are skipped when empty
+}
+
+// PathOverrides holds explicitly requested locations, typically from command-line flags.
+type PathOverrides struct {
+	GlobalDir  string // Global library directory
+	ProjectDir string // Project root, or its .howto directory
+	ConfigFile string // Project config file
+}
+
+// RegisterPathFlags adds the --global-dir, --project-dir and --config flags to fs.
+func RegisterPathFlags(fs *flag.FlagSet, overrides *PathOverrides) {
+	fs.StringVar(&overrides.GlobalDir, "global-dir", "", "global playbook library (defaults to $"+EnvGlobalDir+" or ~/.config/howto)")
+	fs.StringVar(&overrides.ProjectDir, "project-dir", "", "project root or its .howto directory (defaults to $"+EnvProjectDir+" or the current directory)")
+	fs.StringVar(&overrides.ConfigFile, "config", "", "project config file (defaults to config.yaml in the project library)")
+}
+
+// DefaultPaths resolves the global library, the project library in the current directory and the state directory.
+func DefaultPaths() (Paths, error) {
+	return ResolvePaths(PathOverrides{})
+}
+
+// ResolvePaths resolves library locations. Each location is taken from the
+// override if set, then from its environment variable ($HOWTO_GLOBAL_DIR,
+// $HOWTO_PROJECT_DIR), then from the default.
+func ResolvePaths(overrides PathOverrides) (Paths, error) {
+	globalDir := firstNonEmpty(overrides.GlobalDir, os.Getenv(EnvGlobalDir))
+	if globalDir == "" {
+		dir, err := GlobalConfigDir()
+		if err != nil {
+			return Paths{}, fmt.Errorf("failed to resolve global config directory: %w", err)
+		}
+		globalDir = dir
+	}
+
+	var projectDir string
+	if root := firstNonEmpty(overrides.ProjectDir, os.Getenv(EnvProjectDir)); root != "" {
+		projectDir = projectLibraryDir(root)
+	} else {
+		dir, err := ProjectConfigDir()
+		if err != nil {
+			return Paths{}, fmt.Errorf("failed to resolve project config directory: %w", err)
+		}
+		projectDir = dir
+	}
+
+	stateDir, err := StateDir()
+	if err != nil {
+		return Paths{}, fmt.Errorf("failed to resolve state directory: %w", err)
+	}
+
+	return Paths{
+		GlobalDir:  globalDir,
+		ProjectDir: projectDir,
+		ConfigFile: strings.TrimSpace(overrides.ConfigFile),
+		StateDir:   stateDir,
+	}, nil
+}
+
+// projectLibraryDir accepts either a project root or its .howto directory.
+func projectLibraryDir(dir string) string {
+	dir = filepath.Clean(dir)
+	if filepath.Base(dir) == ".howto" {
+		return dir
+	}
+	return ProjectConfigDirFrom(dir)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if trimmed := strings.TrimSpace(value); trimmed != "" {
+			return trimmed
+		}
+	}
+	return ""
+}
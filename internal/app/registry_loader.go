@@ -6,96 +6,277 @@ import (
 	"errors"
 	"fmt"
 	"io/fs"
+	"log"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
 
 	"github.com/yourusername/howto/internal/config"
+	"github.com/yourusername/howto/internal/instructions"
 	"github.com/yourusername/howto/internal/loader"
 	"github.com/yourusername/howto/internal/parser"
 	"github.com/yourusername/howto/internal/registry"
+	"github.com/yourusername/howto/internal/search"
 )
 
+// Environment variables that provide defaults for LoadOptions.
+const (
+	EnvProfile = "HOWTO_PROFILE"
+	EnvAgent   = "HOWTO_AGENT"
+)
+
+// LoadOptions selects which variant of the registry to build.
+type LoadOptions struct {
+	Profile string // Profile from the project config; falls back to $HOWTO_PROFILE
+	Agent   string // Agent identity used to pick agent-specific playbooks; falls back to $HOWTO_AGENT
+	Strict  bool   // Refuse to serve playbook names that are ambiguous within a library
+}
+
+// withDefaults fills unset options from the environment.
+func (o LoadOptions) withDefaults() LoadOptions {
+	if o.Profile == "" {
+		o.Profile = strings.TrimSpace(os.Getenv(EnvProfile))
+	}
+	if o.Agent == "" {
+		o.Agent = os.Getenv(EnvAgent)
+	}
+	o.Agent = parser.NormalizeAgent(o.Agent)
+	return o
+}
+
+// Catalog is a built registry together with what was learned while building it.
+type Catalog struct {
+	Registry    *registry.Snapshot    // Immutable; shared between callers
+	Provenance  registry.Provenance   // Every candidate document per name and why it was or wasn't served
+	Diagnostics []registry.Diagnostic // Problems that did not prevent serving the registry
+	Trust       TrustStatus           // Trust status of the project library
+	Rules       instructions.Set      // Operating rules after global and project config
+	Profile     string                // Profile that was applied, if any
+	Require     []string              // Effective require list of the project config, after the profile
+	Index       *search.Index         // Full-text index over the registry, rebuilt with it
+}
+
 // RegistryLoader exposes a cached view of the playbook registry.
 type RegistryLoader interface {
-	Load() (registry.Registry, error)
+	Load(opts LoadOptions) (*Catalog, error)
 }
 
 // CachedRegistryLoader caches the playbook registry and reloads when source files change.
 type CachedRegistryLoader struct {
-	mu         sync.Mutex
-	globalDir  string
-	projectDir string
+	mu    sync.Mutex
+	paths Paths
 
-	cached    registry.Registry
+	cached    map[LoadOptions]*Catalog
 	signature string
+	logger    *log.Logger
+	strict    bool
 }
 
-// NewCachedRegistryLoader creates a new CachedRegistryLoader rooted at the provided directories.
-func NewCachedRegistryLoader(globalDir, projectDir string) *CachedRegistryLoader {
+// NewCachedRegistryLoader creates a new CachedRegistryLoader rooted at the provided paths.
+func NewCachedRegistryLoader(paths Paths) *CachedRegistryLoader {
 	return &CachedRegistryLoader{
-		globalDir:  globalDir,
-		projectDir: projectDir,
+		paths: paths,
 	}
 }
 
+// SetLogger enables logging of registry diagnostics whenever the registry is rebuilt.
+func (c *CachedRegistryLoader) SetLogger(logger *log.Logger) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logger = logger
+}
+
+// SetStrict makes every load refuse to serve ambiguous playbook names, whatever the options ask for.
+func (c *CachedRegistryLoader) SetStrict(strict bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.strict = strict
+}
+
 // LoadRegistry builds the registry from disk without caching.
-func LoadRegistry(globalDir, projectDir string) (registry.Registry, error) {
-	globalDocs, err := loader.LoadGlobalDocs(globalDir)
+// Problems that do not prevent serving the registry are returned as diagnostics.
+//
+// Unless trust checks are disabled, the project library and its config are
+// only used once they have been approved with `howto trust` and have not
+// changed since.
+func LoadRegistry(paths Paths, opts LoadOptions) (*Catalog, error) {
+	opts = opts.withDefaults()
+
+	trust, err := projectTrust(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	globalDocs, parseFailures, err := loader.LoadGlobalLibrary(paths.GlobalDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load global docs: %w", err)
 	}
 
-	projectDocs, err := loader.LoadProjectDocs(projectDir)
+	globalConfig, err := config.LoadGlobalConfig(paths.GlobalDir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load project docs: %w", err)
+		return nil, fmt.Errorf("failed to load global config: %w", err)
+	}
+
+	var diagnostics []registry.Diagnostic
+	var withheld []parser.Document
+	projectDocs := []parser.Document{}
+	projectConfig := &config.ProjectConfig{Require: []string{}, Exclude: []string{}}
+
+	if trust.Allowed() {
+		var projectFailures []loader.ParseFailure
+		projectDocs, projectFailures, err = loader.LoadProjectLibrary(paths.ProjectDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load project docs: %w", err)
+		}
+		parseFailures = append(parseFailures, projectFailures...)
+	} else {
+		diagnostics = append(diagnostics, trustDiagnostic(paths.ProjectDir, trust))
+		// Withheld playbooks are only read for the provenance; problems in them are reported once trusted
+		withheld, _, _ = loader.LoadProjectLibrary(paths.ProjectDir)
 	}
 
-	projectConfig, err := config.LoadProjectConfig(projectDir)
+	// An explicit config file was chosen by the user and is loaded regardless of
+	// trust; the one inside the project library is withheld with the playbooks,
+	// so a profile asked for cannot be applied.
+	if paths.ConfigFile == "" && !trust.Allowed() && opts.Profile != "" {
+		return nil, &UntrustedProfileError{Profile: opts.Profile, Trust: trust}
+	}
+	if paths.ConfigFile != "" || trust.Allowed() {
+		projectConfig, err = loadProjectConfig(paths)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load project config: %w", err)
+		}
+
+		projectConfig, err = projectConfig.WithProfile(opts.Profile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rules, err := instructions.Default().Apply(globalConfig.Rules)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rules in global config: %w", err)
+	}
+	rules, err = rules.Apply(projectConfig.Rules)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load project config: %w", err)
+		return nil, fmt.Errorf("invalid rules in project config: %w", err)
 	}
 
-	reg := registry.BuildRegistry(globalDocs, projectDocs, projectConfig)
-	return reg, nil
+	reg, provenance, buildDiagnostics := registry.Build(globalDocs, projectDocs, projectConfig, registry.Options{
+		Agent:         opts.Agent,
+		ParseFailures: parseFailures,
+		Strict:        opts.Strict,
+		Withheld:      withheld,
+	})
+	bundles, bundleDiagnostics := registry.ResolveBundles(reg, globalConfig.Bundles, projectConfig.Bundles)
+	diagnostics = append(diagnostics, buildDiagnostics...)
+	diagnostics = append(diagnostics, bundleDiagnostics...)
+
+	snapshot := registry.NewSnapshot(reg).WithBundles(bundles)
+	return &Catalog{
+		Registry:    snapshot,
+		Provenance:  provenance,
+		Diagnostics: diagnostics,
+		Trust:       trust,
+		Rules:       rules,
+		Profile:     projectConfig.Profile,
+		Require:     append([]string(nil), projectConfig.Require...),
+		Index:       search.NewIndex(snapshot.Listed()),
+	}, nil
+}
+
+func loadProjectConfig(paths Paths) (*config.ProjectConfig, error) {
+	if paths.ConfigFile != "" {
+		return config.LoadProjectConfigFile(paths.ConfigFile)
+	}
+	return config.LoadProjectConfig(paths.ProjectDir)
+}
+
+func projectTrust(paths Paths) (TrustStatus, error) {
+	if paths.StateDir == "" {
+		return TrustDisabled, nil
+	}
+
+	store, err := LoadTrustStore(paths.StateDir)
+	if err != nil {
+		return "", err
+	}
+	return store.Status(paths.ProjectDir)
+}
+
+func trustDiagnostic(projectDir string, trust TrustStatus) registry.Diagnostic {
+	return registry.Diagnostic{
+		Severity: registry.SeverityNotice,
+		Path:     projectDir,
+		Message:  fmt.Sprintf("project playbooks withheld: the project library %s; ask the user to review it and run `howto trust`", trust.reason()),
+	}
+}
+
+// UntrustedProfileError reports a profile that cannot be applied because the
+// project config defining it is withheld until the project library is trusted.
+type UntrustedProfileError struct {
+	Profile string
+	Trust   TrustStatus
+}
+
+func (e *UntrustedProfileError) Error() string {
+	return fmt.Sprintf("profile %q cannot be applied: the project library %s, so its config is withheld; ask the user to review it and run `howto trust`", e.Profile, e.Trust.reason())
 }
 
 // Load returns the cached registry, reloading from disk if the source documents changed.
-func (c *CachedRegistryLoader) Load() (registry.Registry, error) {
+// Registries are cached separately for each set of options.
+func (c *CachedRegistryLoader) Load(opts LoadOptions) (*Catalog, error) {
+	opts = opts.withDefaults()
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	currentSignature, err := computeSignature(c.globalDir, c.projectDir)
+	opts.Strict = opts.Strict || c.strict
+
+	currentSignature, err := computeSignature(c.paths.GlobalDir, c.paths.ProjectDir)
 	if err != nil {
 		return nil, err
 	}
+	currentSignature += configSignature(c.paths)
+	if c.paths.StateDir != "" {
+		currentSignature += ":" + fileSignature(TrustFilePath(c.paths.StateDir))
+	}
+
+	if c.signature != currentSignature {
+		c.cached = nil
+	}
 
-	if c.cached != nil && c.signature == currentSignature {
-		return cloneRegistry(c.cached), nil
+	if catalog, ok := c.cached[opts]; ok {
+		return cloneCatalog(catalog), nil
 	}
 
-	reg, err := LoadRegistry(c.globalDir, c.projectDir)
+	catalog, err := LoadRegistry(c.paths, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	c.cached = reg
+	if c.logger != nil {
+		for _, diagnostic := range catalog.Diagnostics {
+			c.logger.Printf("%s: %s", diagnostic.Severity, diagnostic)
+		}
+	}
+
+	if c.cached == nil {
+		c.cached = make(map[LoadOptions]*Catalog)
+	}
+	c.cached[opts] = catalog
 	c.signature = currentSignature
 
-	return cloneRegistry(c.cached), nil
+	return cloneCatalog(catalog), nil
 }
 
-func cloneRegistry(src registry.Registry) registry.Registry {
-	if src == nil {
-		return nil
-	}
-
-	dest := make(registry.Registry, len(src))
-	for k, v := range src {
-		dest[k] = v
-	}
-	return dest
+// cloneCatalog copies the mutable parts of a catalog; the snapshot, provenance and index are immutable and shared
+func cloneCatalog(src *Catalog) *Catalog {
+	dest := *src
+	dest.Diagnostics = append([]registry.Diagnostic(nil), src.Diagnostics...)
+	return &dest
 }
 
 func computeSignature(dirs ...string) (string, error) {
@@ -155,22 +336,43 @@ func computeSignature(dirs ...string) (string, error) {
 	return hex.EncodeToString(hasher.Sum(nil)), nil
 }
 
-// DocumentsToList converts a registry into a sorted slice of documents.
-func DocumentsToList(reg registry.Registry) []parser.Document {
-	names := reg.List()
-	docs := make([]parser.Document, 0, len(names))
-	for _, name := range names {
-		docs = append(docs, reg[name])
+// configSignature summarises the global and project config files and every file
+// they extend, which may live outside the libraries watched by computeSignature
+func configSignature(paths Paths) string {
+	projectConfig := config.ProjectConfigPath(paths.ProjectDir)
+	if paths.ConfigFile != "" {
+		projectConfig = paths.ConfigFile
 	}
-	return docs
+
+	var b strings.Builder
+	for _, path := range []string{config.GlobalConfigPath(paths.GlobalDir), projectConfig} {
+		b.WriteString(":" + fileSignature(path))
+		// A broken chain fails the load itself; the files read so far are still watched
+		files, _ := config.ExtendsChain(path)
+		for _, file := range files {
+			b.WriteString(":" + fileSignature(file))
+		}
+	}
+	return b.String()
 }
 
-// SortedKeys returns registry keys sorted alphabetically.
-func SortedKeys(reg registry.Registry) []string {
-	keys := make([]string, 0, len(reg))
-	for k := range reg {
-		keys = append(keys, k)
+// fileSignature summarises a single file's modification time and size; missing files are allowed.
+func fileSignature(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return path + ":missing"
 	}
+	return fmt.Sprintf("%s:%d:%d", path, info.ModTime().UnixNano(), info.Size())
+}
+
+// DocumentsToList converts a snapshot into a sorted slice of documents.
+func DocumentsToList(snapshot *registry.Snapshot) []parser.Document {
+	return snapshot.GetAll()
+}
+
+// SortedKeys returns snapshot names sorted alphabetically.
+func SortedKeys(snapshot *registry.Snapshot) []string {
+	keys := snapshot.List()
 	sort.Strings(keys)
 	return keys
 }
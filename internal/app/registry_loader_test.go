@@ -3,6 +3,7 @@ package app
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -18,14 +19,14 @@ func TestCachedRegistryLoaderReloadsOnFileChange(t *testing.T) {
 	docPath := filepath.Join(globalDir, "sample.md")
 	writeDoc(t, docPath, "sample", "Initial description", "first version")
 
-	loader := NewCachedRegistryLoader(globalDir, projectDir)
+	loader := NewCachedRegistryLoader(Paths{GlobalDir: globalDir, ProjectDir: projectDir})
 
-	reg1, err := loader.Load()
+	catalog1, err := loader.Load(LoadOptions{})
 	if err != nil {
 		t.Fatalf("Load() failed: %v", err)
 	}
 
-	doc1, ok := reg1.Get("sample")
+	doc1, ok := catalog1.Registry.Get("sample")
 	if !ok {
 		t.Fatalf("expected playbook sample to exist")
 	}
@@ -34,11 +35,11 @@ func TestCachedRegistryLoaderReloadsOnFileChange(t *testing.T) {
 	}
 
 	// Second call should hit the cache and return identical content.
-	reg2, err := loader.Load()
+	catalog2, err := loader.Load(LoadOptions{})
 	if err != nil {
 		t.Fatalf("Load() failed on second call: %v", err)
 	}
-	doc2, ok := reg2.Get("sample")
+	doc2, ok := catalog2.Registry.Get("sample")
 	if !ok {
 		t.Fatalf("expected playbook sample to exist on second load")
 	}
@@ -50,11 +51,11 @@ func TestCachedRegistryLoaderReloadsOnFileChange(t *testing.T) {
 	time.Sleep(20 * time.Millisecond) // ensure modtime changes across filesystems
 	writeDoc(t, docPath, "sample", "Initial description", "updated version")
 
-	reg3, err := loader.Load()
+	catalog3, err := loader.Load(LoadOptions{})
 	if err != nil {
 		t.Fatalf("Load() failed after update: %v", err)
 	}
-	doc3, ok := reg3.Get("sample")
+	doc3, ok := catalog3.Registry.Get("sample")
 	if !ok {
 		t.Fatalf("expected playbook sample to exist after update")
 	}
@@ -63,6 +64,117 @@ func TestCachedRegistryLoaderReloadsOnFileChange(t *testing.T) {
 	}
 }
 
+func TestCachedRegistryLoaderReloadsOnExtendedConfigChange(t *testing.T) {
+	tempDir := t.TempDir()
+	paths := Paths{
+		GlobalDir:  filepath.Join(tempDir, "global"),
+		ProjectDir: filepath.Join(tempDir, "project", ".howto"),
+	}
+	sharedDir := filepath.Join(tempDir, "shared")
+	mustMkdir(t, paths.GlobalDir)
+	mustMkdir(t, paths.ProjectDir)
+	mustMkdir(t, sharedDir)
+	writeFile(t, filepath.Join(paths.GlobalDir, "optional.md"), "---\ndescription: Optional\nrequired: false\n---\nBody")
+	writeFile(t, filepath.Join(paths.ProjectDir, "config.yaml"), "extends: [../../shared/base.yaml]\n")
+	basePath := filepath.Join(sharedDir, "base.yaml")
+	writeFile(t, basePath, "require: []\n")
+
+	loader := NewCachedRegistryLoader(paths)
+	catalog, err := loader.Load(LoadOptions{})
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if _, ok := catalog.Registry.Get("optional"); ok {
+		t.Fatalf("expected optional playbook to be hidden before it is required")
+	}
+
+	time.Sleep(20 * time.Millisecond) // ensure modtime changes across filesystems
+	writeFile(t, basePath, "require: [optional]\n")
+
+	catalog, err = loader.Load(LoadOptions{})
+	if err != nil {
+		t.Fatalf("Load() failed after update: %v", err)
+	}
+	if _, ok := catalog.Registry.Get("optional"); !ok {
+		t.Fatalf("expected a change to the extended config to reload the registry")
+	}
+}
+
+func TestLoadRegistryReportsDuplicateNames(t *testing.T) {
+	tempDir := t.TempDir()
+	paths := Paths{
+		GlobalDir:  filepath.Join(tempDir, "global"),
+		ProjectDir: filepath.Join(tempDir, "project", ".howto"),
+	}
+	mustMkdir(t, filepath.Join(paths.ProjectDir, "old"))
+	mustMkdir(t, filepath.Join(paths.ProjectDir, "new"))
+	writeDoc(t, filepath.Join(paths.ProjectDir, "old", "deploy.md"), "deploy", "Old deploy steps", "Use the old script.")
+	writeDoc(t, filepath.Join(paths.ProjectDir, "new", "deploy.md"), "deploy", "Deploy steps", "Use the pipeline.")
+
+	catalog, err := LoadRegistry(paths, LoadOptions{})
+	if err != nil {
+		t.Fatalf("LoadRegistry() failed: %v", err)
+	}
+	if len(catalog.Diagnostics) != 1 {
+		t.Fatalf("expected one duplicate diagnostic, got %v", catalog.Diagnostics)
+	}
+	message := catalog.Diagnostics[0].Message
+	for _, dir := range []string{"old", "new"} {
+		if !strings.Contains(message, filepath.Join(paths.ProjectDir, dir, "deploy.md")) {
+			t.Errorf("expected diagnostic to name %s/deploy.md, got %q", dir, message)
+		}
+	}
+	if !catalog.Registry.Has("deploy") {
+		t.Fatal("expected the duplicate to be served outside strict mode")
+	}
+
+	catalog, err = LoadRegistry(paths, LoadOptions{Strict: true})
+	if err != nil {
+		t.Fatalf("LoadRegistry() failed: %v", err)
+	}
+	if catalog.Registry.Has("deploy") {
+		t.Fatal("expected the ambiguous playbook to be withheld in strict mode")
+	}
+}
+
+func TestLoadRegistryResolvesBundles(t *testing.T) {
+	tempDir := t.TempDir()
+	paths := Paths{
+		GlobalDir:  filepath.Join(tempDir, "global"),
+		ProjectDir: filepath.Join(tempDir, "project", ".howto"),
+	}
+	mustMkdir(t, paths.GlobalDir)
+	mustMkdir(t, paths.ProjectDir)
+	writeDoc(t, filepath.Join(paths.GlobalDir, "go-lang.md"), "go-lang", "Go rules", "Handle errors.")
+	writeDoc(t, filepath.Join(paths.GlobalDir, "commits.md"), "commits", "Commit rules", "Be brief.")
+	writeFile(t, filepath.Join(paths.GlobalDir, "config.yaml"), "bundles:\n  backend: [go-lang]\n")
+	writeFile(t, filepath.Join(paths.ProjectDir, "config.yaml"), "bundles:\n  backend: [commits, go-lang, missing]\n")
+
+	catalog, err := LoadRegistry(paths, LoadOptions{})
+	if err != nil {
+		t.Fatalf("LoadRegistry() failed: %v", err)
+	}
+
+	docs, err := catalog.Registry.Resolve("@backend")
+	if err != nil {
+		t.Fatalf("Resolve(@backend) failed: %v", err)
+	}
+	if len(docs) != 2 || docs[0].Name != "commits" || docs[1].Name != "go-lang" {
+		t.Fatalf("expected the project definition to win, got %+v", docs)
+	}
+	if len(catalog.Diagnostics) != 1 || !strings.Contains(catalog.Diagnostics[0].Message, `"missing"`) {
+		t.Fatalf("expected a diagnostic for the missing member, got %v", catalog.Diagnostics)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
 func mustMkdir(t *testing.T, path string) {
 	t.Helper()
 	if err := os.MkdirAll(path, 0o755); err != nil {
@@ -0,0 +1,239 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/howto/internal/config"
+)
+
+// TrustStatus describes whether the project library may be served to agents.
+type TrustStatus string
+
+const (
+	TrustNone      TrustStatus = "none"      // No project library present
+	TrustTrusted   TrustStatus = "trusted"   // Approved and unchanged since approval
+	TrustUntrusted TrustStatus = "untrusted" // Never approved
+	TrustChanged   TrustStatus = "changed"   // Modified since it was approved
+	TrustDisabled  TrustStatus = "disabled"  // No state directory, trust checks skipped
+)
+
+// Allowed reports whether project playbooks with this status may be served.
+func (s TrustStatus) Allowed() bool {
+	return s != TrustUntrusted && s != TrustChanged
+}
+
+// reason completes "the project library ..." for a status that is not allowed
+func (s TrustStatus) reason() string {
+	if s == TrustChanged {
+		return "changed since it was approved"
+	}
+	return "has not been approved"
+}
+
+const trustFileName = "trust.json"
+
+// TrustStore records approved project libraries and the content hash they were approved with.
+type TrustStore struct {
+	path     string
+	Projects map[string]TrustEntry `json:"projects"`
+}
+
+// TrustEntry is a single approval.
+type TrustEntry struct {
+	Hash      string    `json:"hash"`
+	TrustedAt time.Time `json:"trusted_at"`
+}
+
+// TrustFilePath returns the location of the trust store inside stateDir.
+func TrustFilePath(stateDir string) string {
+	return filepath.Join(stateDir, trustFileName)
+}
+
+// LoadTrustStore reads the trust store from stateDir. A missing file yields an empty store.
+func LoadTrustStore(stateDir string) (*TrustStore, error) {
+	store := &TrustStore{
+		path:     TrustFilePath(stateDir),
+		Projects: map[string]TrustEntry{},
+	}
+
+	content, err := os.ReadFile(store.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read trust store: %w", err)
+	}
+
+	if err := json.Unmarshal(content, store); err != nil {
+		return nil, fmt.Errorf("failed to parse trust store %s: %w", store.path, err)
+	}
+	if store.Projects == nil {
+		store.Projects = map[string]TrustEntry{}
+	}
+
+	return store, nil
+}
+
+// Status compares the project library on disk against the recorded approval.
+func (s *TrustStore) Status(projectDir string) (TrustStatus, error) {
+	key, err := filepath.Abs(projectDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve project directory: %w", err)
+	}
+
+	hash, files, err := HashProjectLibrary(projectDir)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return TrustNone, nil
+	}
+
+	entry, ok := s.Projects[key]
+	if !ok {
+		return TrustUntrusted, nil
+	}
+	if entry.Hash != hash {
+		return TrustChanged, nil
+	}
+	return TrustTrusted, nil
+}
+
+// Trust records the current contents of the project library as approved and saves the store.
+// It returns the files that were approved.
+func (s *TrustStore) Trust(projectDir string) ([]string, error) {
+	key, err := filepath.Abs(projectDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve project directory: %w", err)
+	}
+
+	hash, files, err := HashProjectLibrary(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no project library found at %s", key)
+	}
+
+	s.Projects[key] = TrustEntry{
+		Hash:      hash,
+		TrustedAt: time.Now().UTC(),
+	}
+
+	if err := s.save(); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func (s *TrustStore) save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	content, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode trust store: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, append(content, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write trust store: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write trust store: %w", err)
+	}
+	return nil
+}
+
+// HashProjectLibrary hashes the path and content of every file in the project
+// library, followed by every config file outside it that the project config
+// reaches through `extends`, since those change what the project serves too.
+// It returns the hash and the files it covered: paths relative to projectDir
+// for the library, absolute paths for extended config files outside it.
+func HashProjectLibrary(projectDir string) (string, []string, error) {
+	info, err := os.Stat(projectDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil, nil
+	} else if err != nil {
+		return "", nil, fmt.Errorf("failed to stat directory %s: %w", projectDir, err)
+	}
+	if !info.IsDir() {
+		return "", nil, fmt.Errorf("path %s is not a directory", projectDir)
+	}
+
+	var files []string
+	err = filepath.WalkDir(projectDir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(projectDir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(relPath))
+		return nil
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to walk directory %s: %w", projectDir, err)
+	}
+
+	sort.Strings(files)
+	if len(files) == 0 {
+		return "", nil, nil
+	}
+
+	paths := make([]string, len(files))
+	for i, relPath := range files {
+		paths[i] = filepath.Join(projectDir, filepath.FromSlash(relPath))
+	}
+
+	// A broken chain fails the load once trusted; the files read so far are still covered
+	chain, _ := config.ExtendsChain(config.ProjectConfigPath(projectDir))
+	for _, path := range chain {
+		if insideDir(projectDir, path) {
+			continue
+		}
+		files = append(files, path)
+		paths = append(paths, path)
+	}
+
+	hasher := sha256.New()
+	for i, relPath := range files {
+		content, err := os.ReadFile(paths[i])
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to read %s: %w", relPath, err)
+		}
+		fileHash := sha256.Sum256(content)
+
+		hasher.Write([]byte(relPath))
+		hasher.Write([]byte{':'})
+		hasher.Write(fileHash[:])
+		hasher.Write([]byte{';'})
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), files, nil
+}
+
+// insideDir reports whether path is dir or lies below it
+func insideDir(dir, path string) bool {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	relPath, err := filepath.Rel(absDir, path)
+	return err == nil && relPath != ".." && !strings.HasPrefix(relPath, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,143 @@
+package app
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yourusername/howto/internal/registry"
+)
+
+func TestTrustStoreStatusTransitions(t *testing.T) {
+	tempDir := t.TempDir()
+	projectDir := filepath.Join(tempDir, "project", ".howto")
+	stateDir := filepath.Join(tempDir, "state")
+
+	store, err := LoadTrustStore(stateDir)
+	if err != nil {
+		t.Fatalf("LoadTrustStore() failed: %v", err)
+	}
+
+	assertTrustStatus(t, store, projectDir, TrustNone)
+
+	mustMkdir(t, projectDir)
+	writeDoc(t, filepath.Join(projectDir, "commits.md"), "commits", "Commit rules", "Use conventional commits.")
+	assertTrustStatus(t, store, projectDir, TrustUntrusted)
+
+	files, err := store.Trust(projectDir)
+	if err != nil {
+		t.Fatalf("Trust() failed: %v", err)
+	}
+	if len(files) != 1 || files[0] != "commits.md" {
+		t.Fatalf("unexpected trusted files: %v", files)
+	}
+
+	// Decisions must survive a reload from disk.
+	store, err = LoadTrustStore(stateDir)
+	if err != nil {
+		t.Fatalf("LoadTrustStore() failed after Trust(): %v", err)
+	}
+	assertTrustStatus(t, store, projectDir, TrustTrusted)
+
+	writeDoc(t, filepath.Join(projectDir, "commits.md"), "commits", "Commit rules", "Push straight to main.")
+	assertTrustStatus(t, store, projectDir, TrustChanged)
+}
+
+func TestTrustCoversExtendedConfig(t *testing.T) {
+	tempDir := t.TempDir()
+	projectDir := filepath.Join(tempDir, "project", ".howto")
+	sharedDir := filepath.Join(tempDir, "shared")
+	mustMkdir(t, projectDir)
+	mustMkdir(t, sharedDir)
+	basePath := filepath.Join(sharedDir, "base.yaml")
+	writeFile(t, basePath, "require: [commits]\n")
+	writeFile(t, filepath.Join(projectDir, "config.yaml"), "extends: [../../shared/base.yaml]\n")
+
+	store, err := LoadTrustStore(filepath.Join(tempDir, "state"))
+	if err != nil {
+		t.Fatalf("LoadTrustStore() failed: %v", err)
+	}
+	files, err := store.Trust(projectDir)
+	if err != nil {
+		t.Fatalf("Trust() failed: %v", err)
+	}
+	if len(files) != 2 || files[0] != "config.yaml" || files[1] != basePath {
+		t.Fatalf("expected the extended config to be approved with the library, got %v", files)
+	}
+	assertTrustStatus(t, store, projectDir, TrustTrusted)
+
+	writeFile(t, basePath, "rules:\n  mode: disable\n")
+	assertTrustStatus(t, store, projectDir, TrustChanged)
+}
+
+func TestLoadRegistryWithholdsUntrustedProjectDocs(t *testing.T) {
+	tempDir := t.TempDir()
+	paths := Paths{
+		GlobalDir:  filepath.Join(tempDir, "global"),
+		ProjectDir: filepath.Join(tempDir, "project", ".howto"),
+		StateDir:   filepath.Join(tempDir, "state"),
+	}
+	mustMkdir(t, paths.GlobalDir)
+	mustMkdir(t, paths.ProjectDir)
+	writeDoc(t, filepath.Join(paths.GlobalDir, "go-lang.md"), "go-lang", "Go rules", "Handle errors.")
+	writeDoc(t, filepath.Join(paths.ProjectDir, "commits.md"), "commits", "Commit rules", "Use conventional commits.")
+
+	loader := NewCachedRegistryLoader(paths)
+
+	catalog, err := loader.Load(LoadOptions{})
+	if err != nil {
+		t.Fatalf("Load() failed: %v", err)
+	}
+	if catalog.Trust != TrustUntrusted {
+		t.Fatalf("expected untrusted project library, got %q", catalog.Trust)
+	}
+	if catalog.Registry.Has("commits") {
+		t.Fatal("expected untrusted project playbook to be withheld")
+	}
+	if !catalog.Registry.Has("go-lang") {
+		t.Fatal("expected global playbook to be served")
+	}
+	if len(catalog.Diagnostics) == 0 {
+		t.Fatal("expected a diagnostic explaining the withheld playbooks")
+	}
+	if severity := catalog.Diagnostics[0].Severity; severity != registry.SeverityNotice || severity.Problem() {
+		t.Fatalf("expected the withheld playbooks to be a notice, not a library problem, got %q", severity)
+	}
+	if candidates := catalog.Provenance["commits"]; len(candidates) != 1 || candidates[0].Outcome != registry.OutcomeUntrusted || candidates[0].Served() {
+		t.Fatalf("expected the withheld project playbook in the provenance as untrusted, got %+v", candidates)
+	}
+
+	var profileErr *UntrustedProfileError
+	if _, err := loader.Load(LoadOptions{Profile: "release"}); !errors.As(err, &profileErr) || profileErr.Trust != TrustUntrusted {
+		t.Fatalf("expected a profile on an untrusted project to be refused, got %v", err)
+	}
+
+	store, err := LoadTrustStore(paths.StateDir)
+	if err != nil {
+		t.Fatalf("LoadTrustStore() failed: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond) // ensure modtime changes across filesystems
+	if _, err := store.Trust(paths.ProjectDir); err != nil {
+		t.Fatalf("Trust() failed: %v", err)
+	}
+
+	catalog, err = loader.Load(LoadOptions{})
+	if err != nil {
+		t.Fatalf("Load() failed after trust: %v", err)
+	}
+	if catalog.Trust != TrustTrusted || !catalog.Registry.Has("commits") {
+		t.Fatalf("expected trusted project playbook after approval, got trust %q", catalog.Trust)
+	}
+}
+
+func assertTrustStatus(t *testing.T, store *TrustStore, projectDir string, expected TrustStatus) {
+	t.Helper()
+	status, err := store.Status(projectDir)
+	if err != nil {
+		t.Fatalf("Status() failed: %v", err)
+	}
+	if status != expected {
+		t.Fatalf("expected trust status %q, got %q", expected, status)
+	}
+}
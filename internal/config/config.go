@@ -4,56 +4,453 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 // ProjectConfig represents the .howto/config.yaml structure
 type ProjectConfig struct {
-	Require []string `yaml:"require"`
+	Require  []string           `yaml:"require"`
+	Exclude  []string           `yaml:"exclude"`
+	Profiles map[string]Profile `yaml:"profiles"`
+	Rules    Rules              `yaml:"rules"`
+	Bundles  Bundles            `yaml:"bundles"`
+
+	// Profile is the name of the profile applied by WithProfile, if any.
+	Profile string `yaml:"-"`
+}
+
+// Profile adjusts the playbook selection for a particular kind of task
+type Profile struct {
+	Require []string `yaml:"require"` // Playbooks added on top of the project require list
+	Exclude []string `yaml:"exclude"` // Playbooks removed from the registry
+}
+
+// GlobalConfig represents the config.yaml file in the global library
+type GlobalConfig struct {
+	Rules   Rules   `yaml:"rules"`
+	Bundles Bundles `yaml:"bundles"`
+}
+
+// Bundles maps bundle names to the playbooks they contain, in the order they are served.
+// Bundle names are written without the leading @ used to fetch them.
+type Bundles map[string][]string
+
+// RulesMode controls how a rules section combines with the rules below it
+type RulesMode string
+
+const (
+	RulesExtend  RulesMode = "extend"  // Add to the inherited rules (default)
+	RulesReplace RulesMode = "replace" // Replace each inherited list that is provided
+	RulesDisable RulesMode = "disable" // Drop all operating rules
+)
+
+// Rules customises the operating rules shown to agents.
+// Built-in rules are layered with the global config first and the project config last.
+type Rules struct {
+	Mode        RulesMode `yaml:"mode"`
+	CLI         []string  `yaml:"cli"`          // Rules printed by `howto`
+	MCP         []string  `yaml:"mcp"`          // Rules rendered into the MCP initialize instructions
+	MCPTemplate string    `yaml:"mcp_template"` // text/template for the MCP initialize instructions
+}
+
+// LoadGlobalConfig loads config.yaml from the global library.
+// Returns empty config if file doesn't exist (not an error). Supports `extends` like LoadProjectConfig.
+func LoadGlobalConfig(globalDir string) (*GlobalConfig, error) {
+	configPath := GlobalConfigPath(globalDir)
+
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		return &GlobalConfig{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to stat global config file: %w", err)
+	}
+
+	var config GlobalConfig
+	if err := decodeConfigTree(configPath, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
 }
 
 // LoadProjectConfig loads the project-scoped config.yaml file
 // Returns empty config if file doesn't exist (not an error)
+//
+// The file may list other config files under `extends`. Paths are resolved
+// relative to the file that declares them and may start with `~/`. Extended
+// files are merged depth-first in the order they are listed, and the
+// extending file is applied last:
+//   - lists are concatenated, dropping duplicate entries
+//   - maps are merged key by key
+//   - scalars from later files override earlier ones
 func LoadProjectConfig(projectDir string) (*ProjectConfig, error) {
-	configPath := filepath.Join(projectDir, "config.yaml")
+	return loadConfigFile(ProjectConfigPath(projectDir), false)
+}
+
+// LoadProjectConfigFile loads a project config from an explicit path.
+// Unlike LoadProjectConfig, a missing file is an error.
+func LoadProjectConfigFile(configPath string) (*ProjectConfig, error) {
+	return loadConfigFile(configPath, true)
+}
 
+func loadConfigFile(configPath string, mustExist bool) (*ProjectConfig, error) {
 	// Check if config file exists
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+	if _, err := os.Stat(configPath); os.IsNotExist(err) && mustExist {
+		return nil, fmt.Errorf("config file %s does not exist", configPath)
+	} else if os.IsNotExist(err) {
 		// No config file - return empty config (not an error)
 		return &ProjectConfig{
 			Require: []string{},
+			Exclude: []string{},
 		}, nil
 	} else if err != nil {
 		return nil, fmt.Errorf("failed to stat config file: %w", err)
 	}
 
-	// Read config file
-	content, err := os.ReadFile(configPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
-	}
-
-	// Parse YAML
+	// Read config file and everything it extends
 	var config ProjectConfig
-	if err := yaml.Unmarshal(content, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config YAML: %w", err)
+	if err := decodeConfigTree(configPath, &config); err != nil {
+		return nil, err
 	}
 
-	// Ensure Require is not nil
+	// Ensure Require and Exclude are not nil
 	if config.Require == nil {
 		config.Require = []string{}
 	}
+	if config.Exclude == nil {
+		config.Exclude = []string{}
+	}
 
 	return &config, nil
 }
 
-// HasRequire checks if a specific doc name is in the require list
+// HasRequire checks if a specific doc name is in the require list, with or without a version range
 func (c *ProjectConfig) HasRequire(name string) bool {
-	for _, req := range c.Require {
-		if req == name {
+	return contains(requireNames(c.Require), name)
+}
+
+// Pin returns the version range the require list pins name to (e.g. "^2" for go-lang@^2),
+// or "" if it is not pinned. When several entries pin the same name the last one wins,
+// so a profile can move a project to another version.
+func (c *ProjectConfig) Pin(name string) string {
+	pin := ""
+	for _, entry := range c.Require {
+		if required, rng := SplitRequirement(entry); required == name && rng != "" {
+			pin = rng
+		}
+	}
+	return pin
+}
+
+// SplitRequirement separates a require entry such as go-lang@^2 into the playbook name and version range
+func SplitRequirement(entry string) (name, versionRange string) {
+	entry = strings.TrimSpace(entry)
+	if at := strings.Index(entry, "@"); at > 0 {
+		return strings.TrimSpace(entry[:at]), strings.TrimSpace(entry[at+1:])
+	}
+	return entry, ""
+}
+
+func requireNames(entries []string) []string {
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i], _ = SplitRequirement(entry)
+	}
+	return names
+}
+
+// HasExclude checks if a specific doc name is in the exclude list
+func (c *ProjectConfig) HasExclude(name string) bool {
+	return contains(c.Exclude, name)
+}
+
+// UnknownProfileError is returned by WithProfile for a profile the project config does not define.
+type UnknownProfileError struct {
+	Name      string
+	Available []string // Profiles the project config defines, sorted
+}
+
+func (e *UnknownProfileError) Error() string {
+	if len(e.Available) == 0 {
+		return fmt.Sprintf("unknown profile %q: no profiles defined in project config", e.Name)
+	}
+	return fmt.Sprintf("unknown profile %q (available: %s)", e.Name, strings.Join(e.Available, ", "))
+}
+
+// WithProfile returns a copy of the config with the named profile applied.
+// An empty name returns the config unchanged; an unknown name is an *UnknownProfileError.
+func (c *ProjectConfig) WithProfile(name string) (*ProjectConfig, error) {
+	if name == "" {
+		return c, nil
+	}
+
+	profile, ok := c.Profiles[name]
+	if !ok {
+		available := make([]string, 0, len(c.Profiles))
+		for key := range c.Profiles {
+			available = append(available, key)
+		}
+		sort.Strings(available)
+		return nil, &UnknownProfileError{Name: name, Available: available}
+	}
+
+	applied := *c
+	applied.Profile = name
+	applied.Require = appendUnique(append([]string{}, c.Require...), profile.Require...)
+	applied.Exclude = appendUnique(append([]string{}, c.Exclude...), profile.Exclude...)
+
+	// A profile that requires a playbook overrides an exclusion inherited from the base config.
+	filtered := applied.Exclude[:0]
+	for _, excluded := range applied.Exclude {
+		if contains(requireNames(profile.Require), excluded) && !contains(profile.Exclude, excluded) {
+			continue
+		}
+		filtered = append(filtered, excluded)
+	}
+	applied.Exclude = filtered
+
+	return &applied, nil
+}
+
+func contains(list []string, name string) bool {
+	for _, item := range list {
+		if item == name {
 			return true
 		}
 	}
 	return false
 }
+
+func appendUnique(list []string, items ...string) []string {
+	for _, item := range items {
+		if !contains(list, item) {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// GlobalConfigPath returns the path of the config file in the global library
+func GlobalConfigPath(globalDir string) string {
+	return filepath.Join(globalDir, "config.yaml")
+}
+
+// ProjectConfigPath returns the path of the config file in the project library
+func ProjectConfigPath(projectDir string) string {
+	return filepath.Join(projectDir, "config.yaml")
+}
+
+// ExtendsChain returns the absolute path of configPath followed by every file it
+// extends, directly or indirectly, in the order they are read. Each file appears
+// once. A missing configPath yields no files. When the chain is broken (a missing
+// base or a cycle), the files read so far are returned with the error.
+func ExtendsChain(configPath string) ([]string, error) {
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	var files []string
+	_, err := loadConfigTree(configPath, nil, &files)
+
+	unique := files[:0]
+	for _, file := range files {
+		if !contains(unique, file) {
+			unique = append(unique, file)
+		}
+	}
+	return unique, err
+}
+
+// decodeConfigTree reads a config file with everything it extends and decodes the merged result into out
+func decodeConfigTree(configPath string, out any) error {
+	merged, err := loadConfigTree(configPath, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	content, err := yaml.Marshal(merged)
+	if err != nil {
+		return fmt.Errorf("failed to encode merged config: %w", err)
+	}
+
+	// Parse YAML
+	if err := yaml.Unmarshal(content, out); err != nil {
+		return fmt.Errorf("failed to parse config YAML: %w", err)
+	}
+	return nil
+}
+
+// loadConfigTree reads a config file, resolves its `extends` list and returns the merged YAML map.
+// chain holds the files currently being resolved and is used to detect cycles.
+// Every file read is appended to files, when it is not nil.
+func loadConfigTree(path string, chain []string, files *[]string) (map[string]any, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve config path %s: %w", path, err)
+	}
+
+	for _, seen := range chain {
+		if seen == absPath {
+			cycle := append(append([]string{}, chain...), absPath)
+			return nil, fmt.Errorf("config extends cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+	chain = append(chain, absPath)
+
+	content, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", absPath, err)
+	}
+	if files != nil {
+		*files = append(*files, absPath)
+	}
+
+	var raw map[string]any
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse config YAML %s: %w", absPath, err)
+	}
+	if raw == nil {
+		raw = map[string]any{}
+	}
+
+	extends, err := extendsList(raw["extends"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid extends in %s: %w", absPath, err)
+	}
+	delete(raw, "extends")
+
+	merged := map[string]any{}
+	for _, entry := range extends {
+		basePath, err := resolveExtendsPath(entry, filepath.Dir(absPath))
+		if err != nil {
+			return nil, fmt.Errorf("invalid extends entry %q in %s: %w", entry, absPath, err)
+		}
+
+		if _, err := os.Stat(basePath); os.IsNotExist(err) {
+			return nil, fmt.Errorf("config %s extends %s, which does not exist", absPath, basePath)
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to stat extended config %s: %w", basePath, err)
+		}
+
+		base, err := loadConfigTree(basePath, chain, files)
+		if err != nil {
+			return nil, err
+		}
+		merged = mergeMaps(merged, base, nil)
+	}
+
+	return mergeMaps(merged, raw, nil), nil
+}
+
+// extendsList normalises the `extends` value, accepting a single string or a list of strings.
+func extendsList(value any) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected a path, got %v", item)
+			}
+			out = append(out, s)
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("expected a path or a list of paths")
+	}
+}
+
+// resolveExtendsPath expands a leading ~/ and resolves relative paths against baseDir.
+func resolveExtendsPath(entry, baseDir string) (string, error) {
+	entry = strings.TrimSpace(entry)
+	if entry == "" {
+		return "", fmt.Errorf("empty path")
+	}
+
+	if entry == "~" || strings.HasPrefix(entry, "~/") {
+		home := os.Getenv("HOME")
+		if home == "" {
+			return "", fmt.Errorf("HOME environment variable not set")
+		}
+		entry = filepath.Join(home, strings.TrimPrefix(entry, "~"))
+	}
+
+	if !filepath.IsAbs(entry) {
+		entry = filepath.Join(baseDir, entry)
+	}
+
+	return filepath.Clean(entry), nil
+}
+
+// mergeMaps applies over on top of base: maps merge recursively, lists are
+// concatenated without duplicates and scalars are replaced. Lists whose order
+// matters (see replacesList) are replaced instead. path holds the keys leading to base.
+func mergeMaps(base, over map[string]any, path []string) map[string]any {
+	out := make(map[string]any, len(base)+len(over))
+	for k, v := range base {
+		out[k] = v
+	}
+
+	for k, v := range over {
+		existing, ok := out[k]
+		if !ok {
+			out[k] = v
+			continue
+		}
+
+		switch ov := v.(type) {
+		case map[string]any:
+			if bv, ok := existing.(map[string]any); ok {
+				out[k] = mergeMaps(bv, ov, append(path[:len(path):len(path)], k))
+				continue
+			}
+		case []any:
+			if bv, ok := existing.([]any); ok && !replacesList(append(path[:len(path):len(path)], k)) {
+				out[k] = mergeLists(bv, ov)
+				continue
+			}
+		}
+		out[k] = v
+	}
+
+	return out
+}
+
+// replacesList reports whether the list at path is replaced rather than
+// concatenated: bundle members and rule lists are ordered, so an extending file
+// that sets one has to be able to shorten or reorder what it inherits.
+func replacesList(path []string) bool {
+	if len(path) < 2 {
+		return false
+	}
+	parent, key := path[len(path)-2], path[len(path)-1]
+	return parent == "bundles" || parent == "rules" && (key == "cli" || key == "mcp")
+}
+
+// mergeLists concatenates two lists, skipping scalar entries already present.
+func mergeLists(base, over []any) []any {
+	out := make([]any, 0, len(base)+len(over))
+	seen := make(map[any]bool)
+
+	for _, list := range [][]any{base, over} {
+		for _, item := range list {
+			switch item.(type) {
+			case map[string]any, []any:
+				out = append(out, item)
+				continue
+			}
+			if seen[item] {
+				continue
+			}
+			seen[item] = true
+			out = append(out, item)
+		}
+	}
+
+	return out
+}
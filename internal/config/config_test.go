@@ -3,6 +3,8 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -138,6 +140,22 @@ func TestHasRequire(t *testing.T) {
 	}
 }
 
+func TestHasRequire_VersionPins(t *testing.T) {
+	config := &ProjectConfig{
+		Require: []string{"go-lang@^2", "commits", "go-lang@~2.1"},
+	}
+
+	if !config.HasRequire("go-lang") || !config.HasRequire("commits") {
+		t.Error("expected pinned and unpinned entries to count as required")
+	}
+	if pin := config.Pin("go-lang"); pin != "~2.1" {
+		t.Errorf("expected the last pin to win, got %q", pin)
+	}
+	if pin := config.Pin("commits"); pin != "" {
+		t.Errorf("expected no pin for commits, got %q", pin)
+	}
+}
+
 func TestHasRequire_EmptyConfig(t *testing.T) {
 	config := &ProjectConfig{
 		Require: []string{},
@@ -166,3 +184,210 @@ func TestLoadProjectConfig_SingleItem(t *testing.T) {
 		t.Errorf("expected 'single-rule', got '%s'", config.Require[0])
 	}
 }
+
+func TestLoadProjectConfig_ExtendsMergesLists(t *testing.T) {
+	tmpDir := setupTestDir(t)
+	sharedDir := filepath.Join(tmpDir, "shared")
+	projectDir := filepath.Join(tmpDir, "project")
+	for _, dir := range []string{sharedDir, projectDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(sharedDir, "base.yaml"), []byte(`require:
+  - shared-rule
+  - common-rule`), 0644); err != nil {
+		t.Fatalf("failed to write base config: %v", err)
+	}
+
+	writeConfigFile(t, projectDir, `extends:
+  - ../shared/base.yaml
+require:
+  - common-rule
+  - local-rule`)
+
+	config, err := LoadProjectConfig(projectDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"shared-rule", "common-rule", "local-rule"}
+	if len(config.Require) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, config.Require)
+	}
+	for i, name := range expected {
+		if config.Require[i] != name {
+			t.Errorf("require[%d] = %q, want %q", i, config.Require[i], name)
+		}
+	}
+}
+
+func TestLoadProjectConfig_ExtendsReplacesOrderedLists(t *testing.T) {
+	tmpDir := setupTestDir(t)
+	projectDir := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(tmpDir, "base.yaml"), []byte(`exclude:
+  - legacy
+bundles:
+  backend: [go-lang, db, commits]
+  docs: [style]
+rules:
+  cli: [Inherited rule.]`), 0644); err != nil {
+		t.Fatalf("failed to write base config: %v", err)
+	}
+
+	writeConfigFile(t, projectDir, `extends: ../base.yaml
+exclude:
+  - old-docs
+bundles:
+  backend: [commits, go-lang]
+rules:
+  cli: [Local rule.]`)
+
+	config, err := LoadProjectConfig(projectDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(config.Bundles["backend"], []string{"commits", "go-lang"}) {
+		t.Errorf("expected the extending file to replace the bundle members, got %v", config.Bundles["backend"])
+	}
+	if !reflect.DeepEqual(config.Bundles["docs"], []string{"style"}) {
+		t.Errorf("expected bundles the extending file does not set to be inherited, got %v", config.Bundles["docs"])
+	}
+	if !reflect.DeepEqual(config.Rules.CLI, []string{"Local rule."}) {
+		t.Errorf("expected the extending file to replace the rule list, got %v", config.Rules.CLI)
+	}
+	// exclude is concatenated, so an inherited entry cannot be undone
+	if !reflect.DeepEqual(config.Exclude, []string{"legacy", "old-docs"}) {
+		t.Errorf("expected exclude lists to be concatenated, got %v", config.Exclude)
+	}
+}
+
+func TestExtendsChain(t *testing.T) {
+	tmpDir := setupTestDir(t)
+	sharedDir := filepath.Join(tmpDir, "shared")
+	projectDir := filepath.Join(tmpDir, "project")
+	for _, dir := range []string{sharedDir, projectDir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+	}
+	for name, content := range map[string]string{
+		"base.yaml":   `extends: [common.yaml]`,
+		"team.yaml":   `extends: [common.yaml]`,
+		"common.yaml": `require: [common-rule]`,
+	} {
+		if err := os.WriteFile(filepath.Join(sharedDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+	}
+	writeConfigFile(t, projectDir, `extends: [../shared/base.yaml, ../shared/team.yaml]`)
+
+	files, err := ExtendsChain(ProjectConfigPath(projectDir))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{
+		ProjectConfigPath(projectDir),
+		filepath.Join(sharedDir, "base.yaml"),
+		filepath.Join(sharedDir, "common.yaml"),
+		filepath.Join(sharedDir, "team.yaml"),
+	}
+	if strings.Join(files, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("ExtendsChain() = %v, want %v", files, expected)
+	}
+
+	if files, err := ExtendsChain(filepath.Join(tmpDir, "missing.yaml")); err != nil || files != nil {
+		t.Errorf("expected no files for a missing config, got %v, %v", files, err)
+	}
+}
+
+func TestLoadProjectConfig_ExtendsHomeDirectory(t *testing.T) {
+	home := setupTestDir(t)
+	t.Setenv("HOME", home)
+
+	teamsDir := filepath.Join(home, ".config", "howto", "teams")
+	if err := os.MkdirAll(teamsDir, 0755); err != nil {
+		t.Fatalf("failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(teamsDir, "payments.yaml"), []byte(`require: [pci-rules]`), 0644); err != nil {
+		t.Fatalf("failed to write team config: %v", err)
+	}
+
+	projectDir := setupTestDir(t)
+	writeConfigFile(t, projectDir, `extends: [~/.config/howto/teams/payments.yaml]`)
+
+	config, err := LoadProjectConfig(projectDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !config.HasRequire("pci-rules") {
+		t.Errorf("expected pci-rules to be inherited, got %v", config.Require)
+	}
+}
+
+func TestLoadProjectConfig_ExtendsMissingFile(t *testing.T) {
+	tmpDir := setupTestDir(t)
+	writeConfigFile(t, tmpDir, `extends: [missing.yaml]`)
+
+	_, err := LoadProjectConfig(tmpDir)
+	if err == nil {
+		t.Fatal("expected error for missing extended config")
+	}
+	if !strings.Contains(err.Error(), "missing.yaml") || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("expected error to name the missing file, got: %v", err)
+	}
+}
+
+func TestLoadProjectConfig_ExtendsCycle(t *testing.T) {
+	tmpDir := setupTestDir(t)
+	if err := os.WriteFile(filepath.Join(tmpDir, "a.yaml"), []byte(`extends: [config.yaml]`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	writeConfigFile(t, tmpDir, `extends: [a.yaml]`)
+
+	_, err := LoadProjectConfig(tmpDir)
+	if err == nil {
+		t.Fatal("expected error for extends cycle")
+	}
+	if !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("expected cycle error, got: %v", err)
+	}
+}
+
+func TestWithProfile(t *testing.T) {
+	tmpDir := setupTestDir(t)
+	writeConfigFile(t, tmpDir, `require: [base-rule]
+profiles:
+  release:
+    require: [release-checklist]
+    exclude: [review-guide]`)
+
+	config, err := LoadProjectConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	release, err := config.WithProfile("release")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !release.HasRequire("base-rule") || !release.HasRequire("release-checklist") {
+		t.Errorf("expected base and profile requires, got %v", release.Require)
+	}
+	if !release.HasExclude("review-guide") {
+		t.Errorf("expected review-guide to be excluded, got %v", release.Exclude)
+	}
+	if config.HasRequire("release-checklist") {
+		t.Error("WithProfile must not modify the original config")
+	}
+
+	if _, err := config.WithProfile("incident"); err == nil || !strings.Contains(err.Error(), "available: release") {
+		t.Errorf("expected unknown profile error listing profiles, got: %v", err)
+	}
+}
@@ -0,0 +1,274 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/howto/internal/config"
+	"github.com/yourusername/howto/internal/parser"
+	"github.com/yourusername/howto/internal/registry"
+	"github.com/yourusername/howto/internal/xref"
+)
+
+// Node kinds.
+const (
+	KindPlaybook = "playbook" // A playbook name
+	KindFile     = "file"     // A document in the global or project library
+	KindConfig   = "config"   // The project config
+	KindBundle   = "bundle"   // A named bundle
+)
+
+// Edge kinds.
+const (
+	EdgeProvides   = "provides"    // file -> playbook; the label is the registry outcome
+	EdgeOverrides  = "overrides"   // project file -> global file it replaced
+	EdgeMerges     = "merges"      // project file -> global file it was merged with
+	EdgeRequires   = "requires"    // config -> playbook
+	EdgeIncludes   = "includes"    // bundle -> member playbook
+	EdgeReferences = "references"  // playbook -> playbook linked with [[...]]; the label is the section
+	EdgeReplacedBy = "replaced-by" // deprecated playbook -> its replacement
+)
+
+// Formats accepted by Render.
+var Formats = []string{"dot", "mermaid", "json"}
+
+// Node is a vertex of the graph. Active nodes are part of what the current project is served.
+type Node struct {
+	ID     string `json:"id"`
+	Kind   string `json:"kind"`
+	Label  string `json:"label"`
+	Active bool   `json:"active"`
+	Source string `json:"source,omitempty"`
+	Path   string `json:"path,omitempty"`
+}
+
+// Edge connects two nodes by ID.
+type Edge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Kind  string `json:"kind"`
+	Label string `json:"label,omitempty"`
+}
+
+// Graph describes how the global and project layers combine into the served catalogue.
+type Graph struct {
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+}
+
+// Input is everything the graph is built from.
+type Input struct {
+	Snapshot   *registry.Snapshot  // What is served
+	Provenance registry.Provenance // Every candidate document per name
+	Require    []string            // Effective require list of the project config
+}
+
+const configID = "config"
+
+// Build assembles the graph. Nodes and edges are sorted so output is stable.
+func Build(in Input) Graph {
+	var g Graph
+	nodes := make(map[string]bool)
+	addNode := func(node Node) {
+		if !nodes[node.ID] {
+			nodes[node.ID] = true
+			g.Nodes = append(g.Nodes, node)
+		}
+	}
+
+	for _, name := range in.Provenance.Names() {
+		addNode(Node{ID: playbookID(name), Kind: KindPlaybook, Label: name, Active: in.Snapshot.Has(name)})
+
+		var replacing *registry.Candidate
+		candidates := in.Provenance[name]
+		for i := range candidates {
+			if candidates[i].Source == parser.SourceProjectScoped && candidates[i].Served() {
+				replacing = &candidates[i]
+			}
+		}
+
+		for _, candidate := range candidates {
+			addNode(Node{
+				ID:     fileID(candidate.Path),
+				Kind:   KindFile,
+				Label:  fmt.Sprintf("%s: %s", candidate.Source, filepath.Base(candidate.Path)),
+				Active: candidate.Served(),
+				Source: candidate.Source.String(),
+				Path:   candidate.Path,
+			})
+			g.Edges = append(g.Edges, Edge{From: fileID(candidate.Path), To: playbookID(name), Kind: EdgeProvides, Label: string(candidate.Outcome)})
+
+			if replacing != nil && candidate.Source == parser.SourceGlobal {
+				switch candidate.Outcome {
+				case registry.OutcomeOverridden:
+					g.Edges = append(g.Edges, Edge{From: fileID(replacing.Path), To: fileID(candidate.Path), Kind: EdgeOverrides})
+				case registry.OutcomeMerged:
+					g.Edges = append(g.Edges, Edge{From: fileID(replacing.Path), To: fileID(candidate.Path), Kind: EdgeMerges})
+				}
+			}
+		}
+	}
+
+	if len(in.Require) > 0 {
+		addNode(Node{ID: configID, Kind: KindConfig, Label: "project config", Active: true})
+		for _, entry := range in.Require {
+			name, versionRange := config.SplitRequirement(entry)
+			addNode(Node{ID: playbookID(name), Kind: KindPlaybook, Label: name, Active: in.Snapshot.Has(name)})
+			g.Edges = append(g.Edges, Edge{From: configID, To: playbookID(name), Kind: EdgeRequires, Label: versionRange})
+		}
+	}
+
+	for _, bundle := range in.Snapshot.Bundles() {
+		id := "bundle:" + bundle
+		addNode(Node{ID: id, Kind: KindBundle, Label: registry.BundlePrefix + bundle, Active: true})
+		for _, member := range in.Snapshot.BundleMembers(bundle) {
+			g.Edges = append(g.Edges, Edge{From: id, To: playbookID(member), Kind: EdgeIncludes})
+		}
+	}
+
+	for _, doc := range in.Snapshot.GetAll() {
+		if doc.Deprecated && doc.ReplacedBy != "" {
+			addNode(Node{ID: playbookID(doc.ReplacedBy), Kind: KindPlaybook, Label: doc.ReplacedBy, Active: in.Snapshot.Has(doc.ReplacedBy)})
+			g.Edges = append(g.Edges, Edge{From: playbookID(doc.Name), To: playbookID(doc.ReplacedBy), Kind: EdgeReplacedBy})
+		}
+		for _, link := range xref.Find(doc.Content) {
+			addNode(Node{ID: playbookID(link.Target), Kind: KindPlaybook, Label: link.Target, Active: in.Snapshot.Has(link.Target)})
+			g.Edges = append(g.Edges, Edge{From: playbookID(doc.Name), To: playbookID(link.Target), Kind: EdgeReferences, Label: link.Section})
+		}
+	}
+
+	sort.SliceStable(g.Nodes, func(i, j int) bool { return g.Nodes[i].ID < g.Nodes[j].ID })
+	sort.SliceStable(g.Edges, func(i, j int) bool {
+		a, b := g.Edges[i], g.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Kind < b.Kind
+	})
+	return g
+}
+
+func playbookID(name string) string {
+	return "playbook:" + name
+}
+
+func fileID(path string) string {
+	return "file:" + path
+}
+
+// Render formats the graph as dot, mermaid or json.
+func Render(g Graph, format string) (string, error) {
+	switch format {
+	case "dot":
+		return g.DOT(), nil
+	case "mermaid":
+		return g.Mermaid(), nil
+	case "json":
+		content, err := json.MarshalIndent(g, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to encode graph: %w", err)
+		}
+		return string(content) + "\n", nil
+	default:
+		return "", fmt.Errorf("unknown graph format %q (expected %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// Colours used for nodes that are and are not active in the current project.
+const (
+	activeColour   = "#c8e6c9"
+	inactiveColour = "#eeeeee"
+)
+
+// DOT renders the graph in Graphviz format.
+func (g Graph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph howto {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [style=filled, fontname=\"Helvetica\"];\n")
+	for _, node := range g.Nodes {
+		shape := map[string]string{KindPlaybook: "box", KindFile: "note", KindConfig: "folder", KindBundle: "component"}[node.Kind]
+		colour, style := activeColour, "filled"
+		if !node.Active {
+			colour, style = inactiveColour, "filled,dashed"
+		}
+		attrs := fmt.Sprintf("label=%s, shape=%s, fillcolor=%q, style=%q", dotQuote(node.Label), shape, colour, style)
+		if node.Path != "" {
+			attrs += ", tooltip=" + dotQuote(node.Path)
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", dotQuote(node.ID), attrs)
+	}
+	for _, edge := range g.Edges {
+		label := edge.Kind
+		if edge.Label != "" {
+			label += ": " + edge.Label
+		}
+		fmt.Fprintf(&b, "  %s -> %s [label=%s];\n", dotQuote(edge.From), dotQuote(edge.To), dotQuote(label))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid renders the graph as a Mermaid flowchart.
+func (g Graph) Mermaid() string {
+	ids := make(map[string]string, len(g.Nodes))
+	var active, inactive []string
+
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for i, node := range g.Nodes {
+		id := fmt.Sprintf("n%d", i)
+		ids[node.ID] = id
+
+		label := mermaidQuote(node.Label)
+		switch node.Kind {
+		case KindFile:
+			fmt.Fprintf(&b, "  %s[/%s/]\n", id, label)
+		case KindConfig:
+			fmt.Fprintf(&b, "  %s{{%s}}\n", id, label)
+		case KindBundle:
+			fmt.Fprintf(&b, "  %s([%s])\n", id, label)
+		default:
+			fmt.Fprintf(&b, "  %s[%s]\n", id, label)
+		}
+
+		if node.Active {
+			active = append(active, id)
+		} else {
+			inactive = append(inactive, id)
+		}
+	}
+	for _, edge := range g.Edges {
+		label := edge.Kind
+		if edge.Label != "" {
+			label += ": " + edge.Label
+		}
+		fmt.Fprintf(&b, "  %s -->|%s| %s\n", ids[edge.From], mermaidQuote(label), ids[edge.To])
+	}
+
+	fmt.Fprintf(&b, "  classDef active fill:%s\n", activeColour)
+	fmt.Fprintf(&b, "  classDef inactive fill:%s,stroke-dasharray: 5 5\n", inactiveColour)
+	if len(active) > 0 {
+		fmt.Fprintf(&b, "  class %s active\n", strings.Join(active, ","))
+	}
+	if len(inactive) > 0 {
+		fmt.Fprintf(&b, "  class %s inactive\n", strings.Join(inactive, ","))
+	}
+	return b.String()
+}
+
+func dotQuote(text string) string {
+	text = strings.ReplaceAll(text, `\`, `\\`)
+	text = strings.ReplaceAll(text, `"`, `\"`)
+	return `"` + text + `"`
+}
+
+func mermaidQuote(text string) string {
+	return `"` + strings.ReplaceAll(text, `"`, "#quot;") + `"`
+}
@@ -0,0 +1,95 @@
+package graph
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/howto/internal/config"
+	"github.com/yourusername/howto/internal/parser"
+	"github.com/yourusername/howto/internal/registry"
+)
+
+func testInput() Input {
+	globalDocs := []parser.Document{
+		{Name: "go-lang", Required: true, Content: "See [[commits#Format]].", Source: parser.SourceGlobal, FilePath: "/global/go-lang.md"},
+		{Name: "commits", Required: true, Content: "## Format\nBe brief.", Source: parser.SourceGlobal, FilePath: "/global/commits.md"},
+		{Name: "optional", Required: false, Source: parser.SourceGlobal, FilePath: "/global/optional.md"},
+	}
+	projectDocs := []parser.Document{
+		{Name: "go-lang", Required: true, Content: "Project rules.", Source: parser.SourceProjectScoped, FilePath: "/project/go-lang.md"},
+	}
+	cfg := &config.ProjectConfig{Require: []string{"commits"}}
+
+	reg, provenance, _ := registry.Build(globalDocs, projectDocs, cfg, registry.Options{})
+	snapshot := registry.NewSnapshot(reg).WithBundles(config.Bundles{"backend": {"go-lang", "commits"}})
+	return Input{Snapshot: snapshot, Provenance: provenance, Require: cfg.Require}
+}
+
+func TestBuild(t *testing.T) {
+	g := Build(testInput())
+
+	nodes := make(map[string]Node)
+	for _, node := range g.Nodes {
+		nodes[node.ID] = node
+	}
+	if !nodes["file:/project/go-lang.md"].Active || nodes["file:/global/go-lang.md"].Active {
+		t.Errorf("expected the project go-lang to be active and the global one not, got %+v", g.Nodes)
+	}
+	if nodes["playbook:optional"].Active {
+		t.Error("expected optional playbook to be inactive")
+	}
+
+	edges := make(map[string]bool)
+	for _, edge := range g.Edges {
+		edges[edge.From+" "+edge.Kind+" "+edge.To] = true
+	}
+	for _, expected := range []string{
+		"file:/project/go-lang.md overrides file:/global/go-lang.md",
+		"config requires playbook:commits",
+		"bundle:backend includes playbook:go-lang",
+		"file:/global/optional.md provides playbook:optional",
+	} {
+		if !edges[expected] {
+			t.Errorf("expected edge %q, got %+v", expected, g.Edges)
+		}
+	}
+
+	// The project go-lang replaced the global one, so its reference is no longer served
+	if edges["playbook:go-lang references playbook:commits"] {
+		t.Error("expected only served content to contribute references")
+	}
+}
+
+func TestRender(t *testing.T) {
+	g := Build(testInput())
+
+	dot, err := Render(g, "dot")
+	if err != nil {
+		t.Fatalf("Render(dot) failed: %v", err)
+	}
+	if !strings.HasPrefix(dot, "digraph howto {") || !strings.Contains(dot, `"playbook:optional" [label="optional", shape=box, fillcolor="#eeeeee"`) {
+		t.Errorf("unexpected dot output:\n%s", dot)
+	}
+
+	mermaid, err := Render(g, "mermaid")
+	if err != nil {
+		t.Fatalf("Render(mermaid) failed: %v", err)
+	}
+	if !strings.HasPrefix(mermaid, "flowchart LR\n") || !strings.Contains(mermaid, "classDef inactive") {
+		t.Errorf("unexpected mermaid output:\n%s", mermaid)
+	}
+
+	raw, err := Render(g, "json")
+	if err != nil {
+		t.Fatalf("Render(json) failed: %v", err)
+	}
+	var decoded Graph
+	if err := json.Unmarshal([]byte(raw), &decoded); err != nil || len(decoded.Nodes) != len(g.Nodes) {
+		t.Errorf("expected json to round-trip, got %v", err)
+	}
+
+	if _, err := Render(g, "svg"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
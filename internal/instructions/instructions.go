@@ -1,6 +1,12 @@
 package instructions
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/yourusername/howto/internal/config"
+)
 
 var llmRules = []string{
 	"Start every new task by running `howto` to refresh the available playbooks.",
@@ -9,18 +15,113 @@ var llmRules = []string{
 	"Stop or escalate if a playbook tells you to pause, ask questions, or hand off to a human.",
 	"When the user shifts focus, rerun `howto` and reload the playbooks that now apply.",
 	"If any call to `howto` fails, report the error instead of guessing; the maintainer needs that signal.",
-	"Limit yourself to playbooks you truly need, but batch them with `&&` if several apply.",
+	"Limit yourself to playbooks you truly need; fetch several at once with `howto <a> <b>` instead of separate calls.",
 	"Reissue `howto` whenever you need a refresher during the session.",
 }
 
-const mcpInstructions = "Use the howto MCP server before making changes:\n" +
-	"- Call `tools/list_playbooks` at the start of every task to refresh the catalogue.\n" +
-	"- Call `tools/call` with `list_playbooks` again whenever the repository or scope changes.\n" +
-	"- Avoid calling for the same playbook multiple times per chat.\n" +
-	"- Fetch each required playbook with `tools/call` (`get_playbook`, argument `name`) before acting.\n" +
-	"- Treat playbook Markdown as mandatory instructions; pause or escalate if guidance conflicts.\n" +
-	"- Surface errors (missing docs, parse failures) to the maintainer instead of guessing.\n" +
-	"- Re-run `list_playbooks` after updating documentation to keep instructions fresh."
+var mcpRules = []string{
+	"Call `tools/list_playbooks` at the start of every task to refresh the catalogue.",
+	"Call `tools/call` with `list_playbooks` again whenever the repository or scope changes.",
+	"Avoid calling for the same playbook multiple times per chat.",
+	"Fetch each required playbook with `tools/call` (`get_playbook`, argument `name`) before acting.",
+	"Treat playbook Markdown as mandatory instructions; pause or escalate if guidance conflicts.",
+	"Surface errors (missing docs, parse failures) to the maintainer instead of guessing.",
+	"Re-run `list_playbooks` after updating documentation to keep instructions fresh.",
+}
+
+// DefaultMCPTemplate renders the MCP initialize instructions. Templates receive TemplateData.
+const DefaultMCPTemplate = "Use the howto MCP server before making changes:\n" +
+	"{{range .Rules}}- {{.}}\n{{end}}"
+
+// TemplateData is available to MCP instruction templates.
+type TemplateData struct {
+	Rules   []string // Effective MCP operating rules
+	Version string   // howto-mcp version
+	Agent   string   // Client name from initialize, if any
+	Profile string   // Active profile, if any
+}
+
+// Set is the effective collection of operating rules after config has been applied.
+type Set struct {
+	CLI         []string // Rules printed by `howto`
+	MCP         []string // Rules rendered into the MCP initialize instructions
+	MCPTemplate string   // Template for the MCP initialize instructions
+}
+
+// Default returns the built-in operating rules.
+func Default() Set {
+	return Set{
+		CLI:         LLMBullets(),
+		MCP:         append([]string(nil), mcpRules...),
+		MCPTemplate: DefaultMCPTemplate,
+	}
+}
+
+// Apply layers a config rules section on top of the set:
+//   - extend (default) appends the configured rules to the current ones
+//   - replace swaps out each list the config provides and keeps the others
+//   - disable removes all rules and the MCP instructions
+//
+// A configured mcp_template replaces the current template in every mode except disable.
+func (s Set) Apply(rules config.Rules) (Set, error) {
+	out := Set{
+		CLI:         append([]string(nil), s.CLI...),
+		MCP:         append([]string(nil), s.MCP...),
+		MCPTemplate: s.MCPTemplate,
+	}
+
+	switch rules.Mode {
+	case "", config.RulesExtend:
+		out.CLI = append(out.CLI, rules.CLI...)
+		out.MCP = append(out.MCP, rules.MCP...)
+	case config.RulesReplace:
+		if rules.CLI != nil {
+			out.CLI = append([]string(nil), rules.CLI...)
+		}
+		if rules.MCP != nil {
+			out.MCP = append([]string(nil), rules.MCP...)
+		}
+	case config.RulesDisable:
+		return Set{}, nil
+	default:
+		return Set{}, fmt.Errorf("invalid rules mode %q (expected extend, replace or disable)", rules.Mode)
+	}
+
+	if strings.TrimSpace(rules.MCPTemplate) != "" {
+		if _, err := template.New("mcp").Parse(rules.MCPTemplate); err != nil {
+			return Set{}, fmt.Errorf("invalid mcp_template: %w", err)
+		}
+		out.MCPTemplate = rules.MCPTemplate
+	}
+
+	return out, nil
+}
+
+// RenderMCP renders the MCP initialize instructions. A set without rules or template renders nothing.
+func (s Set) RenderMCP(data TemplateData) (string, error) {
+	source := s.MCPTemplate
+	if strings.TrimSpace(source) == "" {
+		if len(s.MCP) == 0 {
+			return "", nil
+		}
+		source = DefaultMCPTemplate
+	}
+
+	tmpl, err := template.New("mcp").Parse(source)
+	if err != nil {
+		return "", fmt.Errorf("invalid mcp_template: %w", err)
+	}
+
+	if data.Rules == nil {
+		data.Rules = s.MCP
+	}
+
+	var builder strings.Builder
+	if err := tmpl.Execute(&builder, data); err != nil {
+		return "", fmt.Errorf("failed to render mcp_template: %w", err)
+	}
+	return strings.TrimSpace(builder.String()), nil
+}
 
 // LLMBullets returns the standard operating rules for CLI usage.
 func LLMBullets() []string {
@@ -31,5 +132,6 @@ func LLMBullets() []string {
 
 // MCPUsageInstructions returns guidance for MCP clients on how to consume the server.
 func MCPUsageInstructions() string {
-	return strings.TrimSpace(mcpInstructions)
+	text, _ := Default().RenderMCP(TemplateData{})
+	return text
 }
@@ -0,0 +1,84 @@
+package instructions
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yourusername/howto/internal/config"
+)
+
+func TestMCPUsageInstructionsDefault(t *testing.T) {
+	text := MCPUsageInstructions()
+
+	if !strings.HasPrefix(text, "Use the howto MCP server before making changes:\n- Call `tools/list_playbooks`") {
+		t.Errorf("unexpected default instructions:\n%s", text)
+	}
+	if strings.HasSuffix(text, "\n") {
+		t.Error("expected instructions to be trimmed")
+	}
+}
+
+func TestSetApply(t *testing.T) {
+	base := Default()
+
+	extended, err := base.Apply(config.Rules{CLI: []string{"Run the linter before committing."}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(extended.CLI) != len(base.CLI)+1 || extended.CLI[len(extended.CLI)-1] != "Run the linter before committing." {
+		t.Errorf("expected rule to be appended, got %v", extended.CLI)
+	}
+	if len(extended.MCP) != len(base.MCP) {
+		t.Errorf("expected MCP rules to be untouched, got %v", extended.MCP)
+	}
+
+	replaced, err := base.Apply(config.Rules{Mode: config.RulesReplace, CLI: []string{"Follow our policy."}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(replaced.CLI) != 1 || replaced.CLI[0] != "Follow our policy." {
+		t.Errorf("expected CLI rules to be replaced, got %v", replaced.CLI)
+	}
+	if len(replaced.MCP) != len(base.MCP) {
+		t.Errorf("expected MCP rules to be kept when not provided, got %v", replaced.MCP)
+	}
+
+	disabled, err := base.Apply(config.Rules{Mode: config.RulesDisable})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(disabled.CLI) != 0 || len(disabled.MCP) != 0 {
+		t.Errorf("expected no rules when disabled, got %+v", disabled)
+	}
+	if text, _ := disabled.RenderMCP(TemplateData{}); text != "" {
+		t.Errorf("expected no MCP instructions when disabled, got %q", text)
+	}
+
+	if _, err := base.Apply(config.Rules{Mode: "sometimes"}); err == nil {
+		t.Error("expected error for invalid mode")
+	}
+	if _, err := base.Apply(config.Rules{MCPTemplate: "{{.Rules"}); err == nil {
+		t.Error("expected error for invalid template")
+	}
+}
+
+func TestSetRenderMCPTemplate(t *testing.T) {
+	set, err := Default().Apply(config.Rules{
+		Mode:        config.RulesReplace,
+		MCP:         []string{"Fetch the security checklist first."},
+		MCPTemplate: "Policy for {{.Agent}} (v{{.Version}}):\n{{range .Rules}}* {{.}}\n{{end}}",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	text, err := set.RenderMCP(TemplateData{Agent: "tester", Version: "1.0"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "Policy for tester (v1.0):\n* Fetch the security checklist first."
+	if text != expected {
+		t.Errorf("expected %q, got %q", expected, text)
+	}
+}
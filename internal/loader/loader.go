@@ -10,34 +10,53 @@ import (
 	"github.com/yourusername/howto/internal/parser"
 )
 
+// ParseFailure records a markdown file that was skipped because it could not be parsed
+type ParseFailure struct {
+	Name   string // Playbook name implied by the filename
+	Path   string
+	Source parser.Source
+	Err    error
+}
+
 // LoadGlobalDocs loads all markdown documentation from the global config directory
 func LoadGlobalDocs(configDir string) ([]parser.Document, error) {
-	return loadDocs(configDir, parser.SourceGlobal)
+	docs, _, err := LoadGlobalLibrary(configDir)
+	return docs, err
 }
 
 // LoadProjectDocs loads all markdown documentation from the project-scoped directory
 func LoadProjectDocs(projectDir string) ([]parser.Document, error) {
+	docs, _, err := LoadProjectLibrary(projectDir)
+	return docs, err
+}
+
+// LoadGlobalLibrary loads the global library and also reports the files that failed to parse
+func LoadGlobalLibrary(configDir string) ([]parser.Document, []ParseFailure, error) {
+	return loadDocs(configDir, parser.SourceGlobal)
+}
+
+// LoadProjectLibrary loads the project library and also reports the files that failed to parse
+func LoadProjectLibrary(projectDir string) ([]parser.Document, []ParseFailure, error) {
 	return loadDocs(projectDir, parser.SourceProjectScoped)
 }
 
 // loadDocs loads all markdown files from a directory
-func loadDocs(dir string, source parser.Source) ([]parser.Document, error) {
+func loadDocs(dir string, source parser.Source) ([]parser.Document, []ParseFailure, error) {
 	// Check if directory exists
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
 		// Directory doesn't exist - not an error, just return empty slice
-		return []parser.Document{}, nil
+		return []parser.Document{}, nil, nil
 	} else if err != nil {
-		return nil, fmt.Errorf("failed to stat directory %s: %w", dir, err)
+		return nil, nil, fmt.Errorf("failed to stat directory %s: %w", dir, err)
 	}
 
 	var docs []parser.Document
-	var loadErrors []string
+	var failures []ParseFailure
 
 	// Walk directory and find all .md files
 	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
-			// Log warning but continue walking
-			loadErrors = append(loadErrors, fmt.Sprintf("error accessing path %s: %v", path, err))
+			// Skip unreadable paths but continue walking
 			return nil
 		}
 
@@ -54,8 +73,14 @@ func loadDocs(dir string, source parser.Source) ([]parser.Document, error) {
 		// Parse the file
 		doc, err := parser.ParseFile(path, source)
 		if err != nil {
-			// Log warning but continue processing other files
-			loadErrors = append(loadErrors, fmt.Sprintf("failed to parse %s: %v", path, err))
+			// Record the failure but continue processing other files
+			name, _ := parser.SplitVersion(strings.TrimSuffix(d.Name(), filepath.Ext(d.Name())))
+			failures = append(failures, ParseFailure{
+				Name:   name,
+				Path:   path,
+				Source: source,
+				Err:    err,
+			})
 			return nil
 		}
 
@@ -64,15 +89,10 @@ func loadDocs(dir string, source parser.Source) ([]parser.Document, error) {
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to walk directory %s: %w", dir, err)
+		return nil, nil, fmt.Errorf("failed to walk directory %s: %w", dir, err)
 	}
 
-	// If there were load errors, we could log them here
-	// For now, we'll just silently continue (graceful degradation)
-	// In a production version, you might want to use a logger
-	_ = loadErrors
-
-	return docs, nil
+	return docs, failures, nil
 }
 
 // GetLoadErrors can be used to retrieve errors that occurred during loading
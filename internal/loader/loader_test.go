@@ -177,6 +177,35 @@ Content`)
 	}
 }
 
+func TestLoadGlobalLibrary_ReportsParseFailures(t *testing.T) {
+	tmpDir := setupTestDir(t)
+
+	writeTestFile(t, filepath.Join(tmpDir, "valid.md"), `---
+description: Valid doc
+---
+Content`)
+	writeTestFile(t, filepath.Join(tmpDir, "broken.md"), "no front matter")
+
+	docs, failures, err := LoadGlobalLibrary(tmpDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("expected 1 doc, got %d", len(docs))
+	}
+	if len(failures) != 1 {
+		t.Fatalf("expected 1 parse failure, got %d", len(failures))
+	}
+
+	failure := failures[0]
+	if failure.Name != "broken" || failure.Path != filepath.Join(tmpDir, "broken.md") || failure.Err == nil {
+		t.Errorf("unexpected failure: %+v", failure)
+	}
+	if failure.Source != parser.SourceGlobal {
+		t.Errorf("expected global source, got %v", failure.Source)
+	}
+}
+
 func TestLoadDocs_Subdirectories(t *testing.T) {
 	tmpDir := setupTestDir(t)
 
@@ -14,7 +14,14 @@ import (
 	"github.com/mark3labs/mcp-go/mcp"
 
 	"github.com/yourusername/howto/internal/app"
+	"github.com/yourusername/howto/internal/config"
 	"github.com/yourusername/howto/internal/instructions"
+	"github.com/yourusername/howto/internal/output"
+	"github.com/yourusername/howto/internal/parser"
+	"github.com/yourusername/howto/internal/registry"
+	"github.com/yourusername/howto/internal/suggest"
+	"github.com/yourusername/howto/internal/tokens"
+	"github.com/yourusername/howto/internal/xref"
 )
 
 const (
@@ -26,6 +33,9 @@ const (
 	methodExit        = "exit"
 	methodToolsList   = "tools/list"
 	methodToolsCall   = "tools/call"
+
+	methodResourcesList = "resources/list"
+	methodResourcesRead = "resources/read"
 )
 
 // Error codes aligned with JSON-RPC 2.0 specs.
@@ -35,14 +45,38 @@ const (
 	codeMethodNotFound = -32601
 	codeInvalidParams  = -32602
 	codeInternalError  = -32603
+
+	// codeResourceNotFound is the MCP error for a resources/read URI that names no served playbook.
+	codeResourceNotFound = -32002
 )
 
 // Tool names exposed by the server.
 const (
-	ToolListPlaybooks = "list_playbooks"
-	ToolGetPlaybook   = "get_playbook"
+	ToolListPlaybooks   = "list_playbooks"
+	ToolGetPlaybook     = "get_playbook"
+	ToolSearchPlaybooks = "search_playbooks"
+)
+
+// defaultSearchLimit caps search results when the client does not ask for a limit.
+const defaultSearchLimit = 10
+
+// Values of the format argument of list_playbooks and get_playbook.
+const (
+	formatMarkdown = "markdown"
+	formatXML      = "xml"
 )
 
+var formatProperty = map[string]any{
+	"type":        "string",
+	"enum":        []string{formatMarkdown, formatXML},
+	"description": "Response text format: markdown (default) or xml, which wraps each playbook in a <playbook> element.",
+}
+
+var profileProperty = map[string]any{
+	"type":        "string",
+	"description": "Optional profile from the project config (e.g. review, release) that adjusts the playbook set.",
+}
+
 // Server implements a minimal MCP-compatible JSON-RPC server over stdio.
 type Server struct {
 	decoder *json.Decoder
@@ -53,6 +87,9 @@ type Server struct {
 
 	logger       *log.Logger
 	shuttingDown atomic.Bool
+
+	// agent is the client name reported during initialize, used to pick agent-specific playbooks.
+	agent string
 }
 
 // NewServer constructs an MCP server that reads from in and writes to out.
@@ -145,6 +182,10 @@ func (s *Server) handleRequest(msg rawMessage) error {
 		return s.handleToolsList(msg)
 	case methodToolsCall:
 		return s.handleToolsCall(msg)
+	case methodResourcesList:
+		return s.handleResourcesList(msg)
+	case methodResourcesRead:
+		return s.handleResourcesRead(msg)
 	default:
 		return s.sendError(msg.ID, codeMethodNotFound, fmt.Sprintf("unknown method %q", msg.Method), nil)
 	}
@@ -158,6 +199,23 @@ func (s *Server) handleInitialize(msg rawMessage) error {
 		}
 	}
 
+	if name, ok := params.ClientInfo["name"].(string); ok {
+		s.agent = strings.TrimSpace(name)
+	}
+
+	text := instructions.MCPUsageInstructions()
+	if catalog, err := s.loader.Load(app.LoadOptions{Agent: s.agent}); err != nil {
+		s.logger.Printf("failed to load registry for instructions, using defaults: %v", err)
+	} else if rendered, err := catalog.Rules.RenderMCP(instructions.TemplateData{
+		Version: s.version,
+		Agent:   s.agent,
+		Profile: catalog.Profile,
+	}); err != nil {
+		s.logger.Printf("failed to render instructions, using defaults: %v", err)
+	} else {
+		text = rendered
+	}
+
 	result := initializeResult{
 		ProtocolVersion: mcp.LATEST_PROTOCOL_VERSION,
 		ServerInfo: serverInfo{
@@ -168,8 +226,9 @@ func (s *Server) handleInitialize(msg rawMessage) error {
 			Tools: toolsCapability{
 				ListChanged: true,
 			},
+			Resources: resourcesCapability{},
 		},
-		Instructions: instructions.MCPUsageInstructions(),
+		Instructions: text,
 	}
 
 	return s.sendResult(msg.ID, result)
@@ -188,10 +247,18 @@ func (s *Server) handleToolsList(msg rawMessage) error {
 		Tools: []toolDefinition{
 			{
 				Name:        ToolListPlaybooks,
-				Description: "List available playbooks with their descriptions and origin.",
+				Description: "List available playbooks with their descriptions and origin, most important first.",
 				InputSchema: jsonSchema{
-					Type:                 "object",
-					Properties:           map[string]any{},
+					Type: "object",
+					Properties: map[string]any{
+						"profile": profileProperty,
+						"min_priority": map[string]any{
+							"type":        "string",
+							"enum":        []string{"critical", "high", "normal", "low"},
+							"description": "Only list playbooks with this priority or higher, to fit a tight context budget.",
+						},
+						"format": formatProperty,
+					},
 					Required:             []string{},
 					AdditionalProperties: false,
 				},
@@ -204,13 +271,41 @@ func (s *Server) handleToolsList(msg rawMessage) error {
 					Properties: map[string]any{
 						"name": map[string]any{
 							"type":        "string",
-							"description": "Playbook name from the howto registry.",
+							"description": "Playbook name from the howto registry, or @bundle to fetch every playbook in a bundle.",
+						},
+						"profile": profileProperty,
+						"format":  formatProperty,
+						"max_tokens": map[string]any{
+							"type":        "integer",
+							"description": "Trim the response to at most this many estimated tokens by leaving out whole sections, or whole bundle members; a note lists what was left out.",
+							"minimum":     1,
 						},
 					},
 					Required:             []string{"name"},
 					AdditionalProperties: false,
 				},
 			},
+			{
+				Name:        ToolSearchPlaybooks,
+				Description: "Search playbook names, descriptions, tags and content; returns the best matches with matching lines.",
+				InputSchema: jsonSchema{
+					Type: "object",
+					Properties: map[string]any{
+						"query": map[string]any{
+							"type":        "string",
+							"description": "Free-text query, e.g. \"database migrations\".",
+						},
+						"limit": map[string]any{
+							"type":        "integer",
+							"description": "Maximum number of results (default 10).",
+							"minimum":     1,
+						},
+						"profile": profileProperty,
+					},
+					Required:             []string{"query"},
+					AdditionalProperties: false,
+				},
+			},
 		},
 	}
 
@@ -228,12 +323,35 @@ func (s *Server) handleToolsCall(msg rawMessage) error {
 		arguments = map[string]any{}
 	}
 
+	profile, ok := optionalString(arguments, "profile")
+	if !ok {
+		return s.sendError(msg.ID, codeInvalidParams, "profile must be a string", nil)
+	}
+	opts := app.LoadOptions{Profile: profile, Agent: s.agent}
+
 	switch params.Name {
 	case ToolListPlaybooks:
-		if len(arguments) != 0 {
-			return s.sendError(msg.ID, codeInvalidParams, "list_playbooks does not accept arguments", nil)
+		for key := range arguments {
+			if key != "profile" && key != "min_priority" && key != "format" {
+				return s.sendError(msg.ID, codeInvalidParams, fmt.Sprintf("list_playbooks does not accept argument %q", key), nil)
+			}
+		}
+		rawPriority, ok := optionalString(arguments, "min_priority")
+		if !ok {
+			return s.sendError(msg.ID, codeInvalidParams, "min_priority must be a string", nil)
 		}
-		return s.executeListPlaybooks(msg.ID)
+		minPriority, err := parser.ParsePriority(rawPriority)
+		if err != nil {
+			return s.sendError(msg.ID, codeInvalidParams, err.Error(), nil)
+		}
+		if rawPriority == "" {
+			minPriority = parser.PriorityLow
+		}
+		format, err := parseFormat(arguments)
+		if err != nil {
+			return s.sendError(msg.ID, codeInvalidParams, err.Error(), nil)
+		}
+		return s.executeListPlaybooks(msg.ID, opts, minPriority, format)
 	case ToolGetPlaybook:
 		rawName, ok := arguments["name"]
 		if !ok {
@@ -243,62 +361,203 @@ func (s *Server) handleToolsCall(msg rawMessage) error {
 		if !ok {
 			return s.sendError(msg.ID, codeInvalidParams, "name must be a string", nil)
 		}
-		return s.executeGetPlaybook(msg.ID, strings.TrimSpace(name))
+		format, err := parseFormat(arguments)
+		if err != nil {
+			return s.sendError(msg.ID, codeInvalidParams, err.Error(), nil)
+		}
+		maxTokens := 0
+		if raw, present := arguments["max_tokens"]; present {
+			value, ok := raw.(float64)
+			if !ok || value < 1 || value != float64(int(value)) {
+				return s.sendError(msg.ID, codeInvalidParams, "max_tokens must be a positive integer", nil)
+			}
+			maxTokens = int(value)
+		}
+		return s.executeGetPlaybook(msg.ID, strings.TrimSpace(name), opts, format, maxTokens)
+	case ToolSearchPlaybooks:
+		query, ok := optionalString(arguments, "query")
+		if !ok {
+			return s.sendError(msg.ID, codeInvalidParams, "query must be a string", nil)
+		}
+		if query == "" {
+			return s.sendError(msg.ID, codeInvalidParams, "search_playbooks requires a non-empty query argument", nil)
+		}
+		limit := defaultSearchLimit
+		if raw, present := arguments["limit"]; present {
+			value, ok := raw.(float64)
+			if !ok || value < 1 || value != float64(int(value)) {
+				return s.sendError(msg.ID, codeInvalidParams, "limit must be a positive integer", nil)
+			}
+			limit = int(value)
+		}
+		return s.executeSearchPlaybooks(msg.ID, query, limit, opts)
 	default:
 		return s.sendError(msg.ID, codeInvalidParams, fmt.Sprintf("unknown tool %q", params.Name), nil)
 	}
 }
 
-func (s *Server) executeListPlaybooks(id json.RawMessage) error {
-	reg, err := s.loader.Load()
+func (s *Server) executeListPlaybooks(id json.RawMessage, opts app.LoadOptions, minPriority parser.Priority, format string) error {
+	catalog, err := s.loader.Load(opts)
 	if err != nil {
-		s.logger.Printf("failed to load registry: %v", err)
-		return s.sendError(id, codeInternalError, "failed to load playbook registry", nil)
+		return s.sendLoadError(id, err)
 	}
 
-	docs := app.DocumentsToList(reg)
+	docs := catalog.Registry.AtLeast(minPriority)
 	var builder strings.Builder
 
+	if !catalog.Trust.Allowed() {
+		builder.WriteString(fmt.Sprintf("Note: project playbooks withheld (project library %s). Ask the user to review them and run `howto trust`.\n\n", catalog.Trust))
+	}
+
 	if len(docs) == 0 {
 		builder.WriteString("No playbooks available.")
 	} else {
 		builder.WriteString("Available playbooks:\n")
 		for _, doc := range docs {
-			builder.WriteString(fmt.Sprintf("- %s — %s\n", doc.Name, oneLine(doc.Description)))
+			builder.WriteString(fmt.Sprintf("- %s — %s (%s)\n", doc.Name, oneLine(doc.Description), output.FormatTokens(tokens.Estimate(doc.Content))))
+		}
+	}
+	if omitted := len(catalog.Registry.Listed()) - len(docs); omitted > 0 {
+		builder.WriteString(fmt.Sprintf("\n(%d playbook(s) below %s priority omitted.)\n", omitted, minPriority))
+	}
+	if bundles := catalog.Registry.Bundles(); len(bundles) > 0 {
+		builder.WriteString("\nBundles (fetch all members at once with get_playbook name \"@<bundle>\"):\n")
+		for _, bundle := range bundles {
+			builder.WriteString(fmt.Sprintf("- %s%s — %s\n", registry.BundlePrefix, bundle, strings.Join(catalog.Registry.BundleMembers(bundle), ", ")))
 		}
 	}
 
+	hashes := make(map[string]string, len(docs))
+	entries := make([]output.CatalogueEntry, 0, len(docs))
+	for _, doc := range docs {
+		hashes[doc.Name] = catalog.Registry.Hash(doc.Name)
+		entries = append(entries, output.NewCatalogueEntry(catalog.Registry, doc))
+	}
+
+	text := strings.TrimRight(builder.String(), "\n")
+	if format == formatXML {
+		catalogue := output.NewCatalogue(catalog.Registry, nil, string(catalog.Trust), nil)
+		catalogue.Playbooks = entries
+		text = strings.TrimRight(output.FormatXMLCatalogue(catalogue), "\n")
+	}
+
 	return s.sendResult(id, toolResponse{
 		Content: []responseContent{
 			{
 				Type: "text",
-				Text: strings.TrimRight(builder.String(), "\n"),
+				Text: text,
 			},
 		},
+		Metadata: map[string]any{
+			"schema_version": output.SchemaVersion,
+			"revision":       catalog.Registry.Revision(),
+			"hashes":         hashes,
+			"playbooks":      entries,
+		},
 	})
 }
 
-func (s *Server) executeGetPlaybook(id json.RawMessage, name string) error {
+func (s *Server) executeGetPlaybook(id json.RawMessage, name string, opts app.LoadOptions, format string, maxTokens int) error {
 	if name == "" {
 		return s.sendError(id, codeInvalidParams, "name cannot be empty", nil)
 	}
 
-	reg, err := s.loader.Load()
+	catalog, err := s.loader.Load(opts)
 	if err != nil {
-		s.logger.Printf("failed to load registry: %v", err)
-		return s.sendError(id, codeInternalError, "failed to load playbook registry", nil)
+		return s.sendLoadError(id, err)
 	}
 
-	doc, ok := reg.Get(name)
-	if !ok {
-		return s.sendError(id, codeInvalidParams, fmt.Sprintf("unknown playbook %q", name), nil)
+	if registry.IsBundleName(name) {
+		return s.executeGetBundle(id, name, catalog, format, maxTokens)
 	}
 
-	text := doc.Content
-	if strings.TrimSpace(text) == "" {
+	doc, err := catalog.Registry.Lookup(name)
+	if err != nil {
+		var unknown *registry.UnknownPlaybookError
+		if errors.As(err, &unknown) {
+			message := fmt.Sprintf("unknown playbook %q", name)
+			if hint := suggest.Phrase(unknown.Suggestions); hint != "" {
+				message += "; " + hint
+			}
+			data := map[string]any{
+				"name":        name,
+				"suggestions": append([]string{}, unknown.Suggestions...),
+			}
+			if candidates, ok := catalog.Provenance[name]; ok {
+				data["provenance"] = output.NewCandidates(candidates)
+			}
+			return s.sendError(id, codeInvalidParams, message, data)
+		}
+		return s.sendError(id, codeInternalError, err.Error(), nil)
+	}
+
+	playbook := output.NewPlaybook(catalog.Registry, doc, xref.StyleMCP)
+	playbook.Trust = string(catalog.Trust)
+	if candidates, ok := catalog.Provenance[name]; ok {
+		playbook.Provenance = output.NewCandidates(candidates)
+	}
+	playbook.Content, playbook.OmittedSections, err = output.FitContent(name, playbook.Content, maxTokens)
+	if err != nil {
+		return s.sendError(id, codeInvalidParams, err.Error(), nil)
+	}
+
+	text := playbook.Content
+	switch {
+	case format == formatXML:
+		text = strings.TrimRight(output.FormatXMLPlaybook(playbook), "\n")
+	case strings.TrimSpace(text) == "":
 		text = "(empty playbook)"
 	}
 
+	return s.sendResult(id, toolResponse{
+		Content: []responseContent{
+			{
+				Type: "text",
+				Text: text,
+			},
+		},
+		Metadata: playbook.PlaybookInfo,
+	})
+}
+
+func (s *Server) executeGetBundle(id json.RawMessage, name string, catalog *app.Catalog, format string, maxTokens int) error {
+	docs, err := catalog.Registry.Resolve(name)
+	if err != nil {
+		var unknown *registry.UnknownPlaybookError
+		if errors.As(err, &unknown) {
+			message := fmt.Sprintf("unknown bundle %q", name)
+			if hint := suggest.Phrase(unknown.Suggestions); hint != "" {
+				message += "; " + hint
+			}
+			return s.sendError(id, codeInvalidParams, message, map[string]any{
+				"name":        name,
+				"suggestions": append([]string{}, unknown.Suggestions...),
+			})
+		}
+		return s.sendError(id, codeInvalidParams, err.Error(), nil)
+	}
+
+	members := make([]output.CatalogueEntry, 0, len(docs))
+	for _, doc := range docs {
+		members = append(members, output.NewCatalogueEntry(catalog.Registry, doc))
+	}
+
+	var text string
+	if format == formatXML {
+		bundle := output.NewBundle(catalog.Registry, docs, name, xref.StyleMCP)
+		bundle.Trust = string(catalog.Trust)
+		if bundle.Playbooks, err = output.FitPlaybooks(bundle.Playbooks, maxTokens); err != nil {
+			return s.sendError(id, codeInvalidParams, err.Error(), nil)
+		}
+		text = strings.TrimRight(output.FormatXMLPlaybooks(bundle), "\n")
+	} else {
+		fitted, err := output.FitDocuments(xref.RewriteDocuments(output.ApplyDeprecations(catalog.Registry, docs), xref.StyleMCP), maxTokens)
+		if err != nil {
+			return s.sendError(id, codeInvalidParams, err.Error(), nil)
+		}
+		text = output.FormatPlaybooks(fitted)
+	}
+
 	return s.sendResult(id, toolResponse{
 		Content: []responseContent{
 			{
@@ -307,13 +566,151 @@ func (s *Server) executeGetPlaybook(id json.RawMessage, name string) error {
 			},
 		},
 		Metadata: map[string]any{
-			"name":        doc.Name,
-			"description": doc.Description,
-			"source":      doc.Source.String(),
+			"name":     name,
+			"bundle":   true,
+			"members":  members,
+			"trust":    string(catalog.Trust),
+			"revision": catalog.Registry.Revision(),
+		},
+	})
+}
+
+func (s *Server) executeSearchPlaybooks(id json.RawMessage, query string, limit int, opts app.LoadOptions) error {
+	catalog, err := s.loader.Load(opts)
+	if err != nil {
+		return s.sendLoadError(id, err)
+	}
+
+	results := catalog.Index.Search(query, limit)
+
+	var builder strings.Builder
+	matches := make([]map[string]any, 0, len(results))
+	if len(results) == 0 {
+		builder.WriteString(fmt.Sprintf("No playbooks match %q.", query))
+	} else {
+		builder.WriteString(fmt.Sprintf("Playbooks matching %q:\n", query))
+		for _, result := range results {
+			builder.WriteString(fmt.Sprintf("- %s — %s\n", result.Name, oneLine(result.Description)))
+
+			snippets := make([]map[string]any, 0, len(result.Snippets))
+			for _, snippet := range result.Snippets {
+				builder.WriteString(fmt.Sprintf("    %d: %s\n", snippet.Line, snippet.Text))
+				snippets = append(snippets, map[string]any{"line": snippet.Line, "text": snippet.Text})
+			}
+
+			matches = append(matches, map[string]any{
+				"name":     result.Name,
+				"hash":     catalog.Registry.Hash(result.Name),
+				"score":    result.Score,
+				"snippets": snippets,
+			})
+		}
+	}
+
+	return s.sendResult(id, toolResponse{
+		Content: []responseContent{
+			{
+				Type: "text",
+				Text: strings.TrimRight(builder.String(), "\n"),
+			},
+		},
+		Metadata: map[string]any{
+			"query":    query,
+			"results":  matches,
+			"revision": catalog.Registry.Revision(),
+		},
+	})
+}
+
+// handleResourcesList lists every served playbook as a howto:// resource, so the
+// links in playbook bodies can be followed with resources/read.
+func (s *Server) handleResourcesList(msg rawMessage) error {
+	catalog, err := s.loader.Load(app.LoadOptions{Agent: s.agent})
+	if err != nil {
+		return s.sendLoadError(msg.ID, err)
+	}
+
+	docs := catalog.Registry.Listed()
+	resources := make([]resourceDefinition, 0, len(docs))
+	for _, doc := range docs {
+		resources = append(resources, resourceDefinition{
+			URI:         xref.URIScheme + doc.Name,
+			Name:        doc.Name,
+			Description: oneLine(doc.Description),
+			MimeType:    "text/markdown",
+		})
+	}
+	return s.sendResult(msg.ID, resourcesListResult{Resources: resources})
+}
+
+// handleResourcesRead returns the playbook a howto:// URI points to, as get_playbook
+// would. A section fragment is an anchor within the playbook; the whole playbook is returned.
+func (s *Server) handleResourcesRead(msg rawMessage) error {
+	var params resourcesReadParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		return s.sendError(msg.ID, codeInvalidParams, "invalid resources/read params", map[string]any{"error": err.Error()})
+	}
+	name, ok := xref.PlaybookName(params.URI)
+	if !ok {
+		return s.sendError(msg.ID, codeInvalidParams, fmt.Sprintf("unsupported resource URI %q (expected %s<name>)", params.URI, xref.URIScheme), nil)
+	}
+
+	catalog, err := s.loader.Load(app.LoadOptions{Agent: s.agent})
+	if err != nil {
+		return s.sendLoadError(msg.ID, err)
+	}
+
+	doc, err := catalog.Registry.Lookup(name)
+	if err != nil {
+		var unknown *registry.UnknownPlaybookError
+		if errors.As(err, &unknown) {
+			message := fmt.Sprintf("unknown playbook %q", name)
+			if hint := suggest.Phrase(unknown.Suggestions); hint != "" {
+				message += "; " + hint
+			}
+			return s.sendError(msg.ID, codeResourceNotFound, message, map[string]any{
+				"uri":         params.URI,
+				"suggestions": append([]string{}, unknown.Suggestions...),
+			})
+		}
+		return s.sendError(msg.ID, codeInternalError, err.Error(), nil)
+	}
+
+	playbook := output.NewPlaybook(catalog.Registry, doc, xref.StyleMCP)
+	return s.sendResult(msg.ID, resourcesReadResult{
+		Contents: []resourceContent{
+			{
+				URI:      params.URI,
+				MimeType: "text/markdown",
+				Text:     playbook.Content,
+			},
 		},
 	})
 }
 
+// sendLoadError reports a failed registry load. An unknown profile is the caller's
+// mistake and comes back as invalid params with the profiles that exist; so does
+// a profile asked for while the project library is not trusted.
+func (s *Server) sendLoadError(id json.RawMessage, err error) error {
+	var unknownProfile *config.UnknownProfileError
+	if errors.As(err, &unknownProfile) {
+		return s.sendError(id, codeInvalidParams, err.Error(), map[string]any{
+			"profile":   unknownProfile.Name,
+			"available": append([]string{}, unknownProfile.Available...),
+		})
+	}
+	var untrustedProfile *app.UntrustedProfileError
+	if errors.As(err, &untrustedProfile) {
+		return s.sendError(id, codeInvalidParams, err.Error(), map[string]any{
+			"profile": untrustedProfile.Profile,
+			"trust":   string(untrustedProfile.Trust),
+		})
+	}
+
+	s.logger.Printf("failed to load registry: %v", err)
+	return s.sendError(id, codeInternalError, "failed to load playbook registry", map[string]any{"error": err.Error()})
+}
+
 func (s *Server) sendResult(id json.RawMessage, result any) error {
 	resp := response{
 		JSONRPC: jsonRPCVersion,
@@ -336,6 +733,34 @@ func (s *Server) sendError(id json.RawMessage, code int, message string, data an
 	return s.encoder.Encode(resp)
 }
 
+// parseFormat reads the optional format argument, defaulting to Markdown
+func parseFormat(arguments map[string]any) (string, error) {
+	format, ok := optionalString(arguments, "format")
+	if !ok {
+		return "", fmt.Errorf("format must be a string")
+	}
+	switch format {
+	case "":
+		return formatMarkdown, nil
+	case formatMarkdown, formatXML:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown format %q (expected %s or %s)", format, formatMarkdown, formatXML)
+}
+
+// optionalString reads an optional string argument; ok is false when the value has the wrong type.
+func optionalString(arguments map[string]any, key string) (string, bool) {
+	raw, present := arguments[key]
+	if !present || raw == nil {
+		return "", true
+	}
+	value, ok := raw.(string)
+	if !ok {
+		return "", false
+	}
+	return strings.TrimSpace(value), true
+}
+
 func oneLine(text string) string {
 	trimmed := strings.TrimSpace(text)
 	if trimmed == "" {
@@ -373,13 +798,43 @@ type serverInfo struct {
 }
 
 type capabilities struct {
-	Tools toolsCapability `json:"tools"`
+	Tools     toolsCapability     `json:"tools"`
+	Resources resourcesCapability `json:"resources"`
 }
 
 type toolsCapability struct {
 	ListChanged bool `json:"listChanged"`
 }
 
+type resourcesCapability struct {
+	ListChanged bool `json:"listChanged"`
+}
+
+type resourcesListResult struct {
+	Resources []resourceDefinition `json:"resources"`
+}
+
+type resourceDefinition struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType"`
+}
+
+type resourcesReadParams struct {
+	URI string `json:"uri"`
+}
+
+type resourcesReadResult struct {
+	Contents []resourceContent `json:"contents"`
+}
+
+type resourceContent struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
 type toolsListResult struct {
 	Tools []toolDefinition `json:"tools"`
 }
@@ -404,7 +859,7 @@ type toolsCallParams struct {
 
 type toolResponse struct {
 	Content  []responseContent `json:"content"`
-	Metadata map[string]any    `json:"metadata,omitempty"`
+	Metadata any               `json:"metadata,omitempty"`
 }
 
 type responseContent struct {
@@ -8,9 +8,14 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/yourusername/howto/internal/app"
+	"github.com/yourusername/howto/internal/config"
+	"github.com/yourusername/howto/internal/instructions"
 	"github.com/yourusername/howto/internal/parser"
 	"github.com/yourusername/howto/internal/registry"
+	"github.com/yourusername/howto/internal/search"
 )
 
 func TestServerHandlesHandshakeAndTools(t *testing.T) {
@@ -61,8 +66,8 @@ func TestServerHandlesHandshakeAndTools(t *testing.T) {
 		t.Fatalf("tools/list returned error: %+v", messages[1].Error)
 	}
 	result, ok := messages[1].Result["tools"].([]any)
-	if !ok || len(result) != 2 {
-		t.Fatalf("expected two tools, got %#v", messages[1].Result["tools"])
+	if !ok || len(result) != 3 {
+		t.Fatalf("expected three tools, got %#v", messages[1].Result["tools"])
 	}
 
 	// list_playbooks
@@ -77,6 +82,15 @@ func TestServerHandlesHandshakeAndTools(t *testing.T) {
 		t.Fatalf("get_playbook returned error: %+v", messages[3].Error)
 	}
 	verifyContentContains(t, messages[3].Result, "Always follow the plays.")
+
+	metadata, ok := messages[3].Result["metadata"].(map[string]any)
+	if !ok {
+		t.Fatalf("get_playbook response missing metadata")
+	}
+	snapshot := registry.NewSnapshot(loader.reg)
+	if metadata["revision"] != snapshot.Revision() || metadata["hash"] != snapshot.Hash("core-principles") {
+		t.Fatalf("expected revision %q and hash %q, got %v", snapshot.Revision(), snapshot.Hash("core-principles"), metadata)
+	}
 }
 
 func TestServerGetPlaybookError(t *testing.T) {
@@ -104,25 +118,498 @@ func TestServerGetPlaybookError(t *testing.T) {
 	}
 }
 
+func TestServerGetPlaybookSuggestsNames(t *testing.T) {
+	loader := &stubLoader{
+		reg: registry.Registry{
+			"go-lang": {Name: "go-lang", Description: "Go conventions", Content: "Handle errors.", Aliases: []string{"golang"}},
+		},
+	}
+
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"get_playbook","arguments":{"name":"golang"}}}`
+	var output bytes.Buffer
+	server := NewServer(strings.NewReader(input), &output, loader, "test", log.New(io.Discard, "", 0))
+
+	if err := server.Serve(); err != nil {
+		t.Fatalf("Serve() returned error: %v", err)
+	}
+
+	messages := decodeLines(t, output.String())
+	if len(messages) != 1 || messages[0].Error == nil {
+		t.Fatalf("expected one error response, got %+v", messages)
+	}
+	if !strings.Contains(messages[0].Error.Message, `did you mean "go-lang"?`) {
+		t.Fatalf("expected suggestion in error message, got %q", messages[0].Error.Message)
+	}
+
+	var data struct {
+		Name        string   `json:"name"`
+		Suggestions []string `json:"suggestions"`
+	}
+	if err := json.Unmarshal(messages[0].Error.Data, &data); err != nil {
+		t.Fatalf("failed to decode error data: %v", err)
+	}
+	if data.Name != "golang" || len(data.Suggestions) != 1 || data.Suggestions[0] != "go-lang" {
+		t.Fatalf("unexpected error data: %+v", data)
+	}
+}
+
+func TestServerGetPlaybookReportsProvenance(t *testing.T) {
+	loader := &stubLoader{
+		reg: registry.Registry{},
+		provenance: registry.Provenance{
+			"optional-rule": {{
+				Source:  parser.SourceGlobal,
+				Path:    "/global/optional-rule.md",
+				Outcome: registry.OutcomeNotRequired,
+				Reason:  "required: false and not listed in require",
+			}},
+		},
+	}
+
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"get_playbook","arguments":{"name":"optional-rule"}}}`
+	var output bytes.Buffer
+	server := NewServer(strings.NewReader(input), &output, loader, "test", log.New(io.Discard, "", 0))
+
+	if err := server.Serve(); err != nil {
+		t.Fatalf("Serve() returned error: %v", err)
+	}
+
+	messages := decodeLines(t, output.String())
+	if len(messages) != 1 || messages[0].Error == nil {
+		t.Fatalf("expected one error response, got %+v", messages)
+	}
+
+	var data struct {
+		Provenance []map[string]string `json:"provenance"`
+	}
+	if err := json.Unmarshal(messages[0].Error.Data, &data); err != nil {
+		t.Fatalf("failed to decode error data: %v", err)
+	}
+	if len(data.Provenance) != 1 {
+		t.Fatalf("expected one provenance entry, got %+v", data.Provenance)
+	}
+	entry := data.Provenance[0]
+	if entry["source"] != "global" || entry["path"] != "/global/optional-rule.md" || entry["outcome"] != "not-required" {
+		t.Fatalf("unexpected provenance entry: %+v", entry)
+	}
+}
+
+func TestServerListPlaybooksMinPriority(t *testing.T) {
+	loader := &stubLoader{
+		reg: registry.Registry{
+			"security": {Name: "security", Description: "Security rules", Priority: parser.PriorityCritical},
+			"style":    {Name: "style", Description: "Style nits", Priority: parser.PriorityLow},
+		},
+	}
+
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"list_playbooks","arguments":{"min_priority":"high"}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"list_playbooks","arguments":{"min_priority":"urgent"}}}`,
+	}, "\n")
+	var output bytes.Buffer
+	server := NewServer(strings.NewReader(input), &output, loader, "test", log.New(io.Discard, "", 0))
+
+	if err := server.Serve(); err != nil {
+		t.Fatalf("Serve() returned error: %v", err)
+	}
+
+	messages := decodeLines(t, output.String())
+	if len(messages) != 2 || messages[0].Error != nil {
+		t.Fatalf("expected a successful listing, got %+v", messages)
+	}
+	verifyContentContains(t, messages[0].Result, "- security")
+	verifyContentContains(t, messages[0].Result, "1 playbook(s) below high priority omitted")
+	if text := messages[0].Result["content"].([]any)[0].(map[string]any)["text"].(string); strings.Contains(text, "style") {
+		t.Fatalf("expected low priority playbook to be omitted, got %q", text)
+	}
+
+	if messages[1].Error == nil || messages[1].Error.Code != codeInvalidParams {
+		t.Fatalf("expected invalid params error for unknown priority, got %+v", messages[1].Error)
+	}
+}
+
+func TestServerGetPlaybookBundle(t *testing.T) {
+	loader := &stubLoader{
+		reg: registry.Registry{
+			"go-lang": {Name: "go-lang", Description: "Go conventions", Content: "Handle errors. See [[commits]]."},
+			"commits": {Name: "commits", Description: "Commit rules", Content: "Be brief."},
+		},
+		bundles: config.Bundles{"backend": {"go-lang", "commits"}},
+	}
+
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"get_playbook","arguments":{"name":"@backend"}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"get_playbook","arguments":{"name":"@frontend"}}}`,
+	}, "\n")
+	var output bytes.Buffer
+	server := NewServer(strings.NewReader(input), &output, loader, "test", log.New(io.Discard, "", 0))
+
+	if err := server.Serve(); err != nil {
+		t.Fatalf("Serve() returned error: %v", err)
+	}
+
+	messages := decodeLines(t, output.String())
+	if len(messages) != 2 || messages[0].Error != nil {
+		t.Fatalf("expected a successful bundle response, got %+v", messages)
+	}
+	verifyContentContains(t, messages[0].Result, "# Playbook: go-lang\n\nHandle errors. See [commits](howto://playbook/commits).\n\n---\n\n# Playbook: commits")
+
+	metadata := messages[0].Result["metadata"].(map[string]any)
+	if members, ok := metadata["members"].([]any); !ok || len(members) != 2 {
+		t.Fatalf("expected two bundle members in metadata, got %v", metadata["members"])
+	}
+
+	if messages[1].Error == nil || messages[1].Error.Code != codeInvalidParams {
+		t.Fatalf("expected invalid params error for unknown bundle, got %+v", messages[1].Error)
+	}
+}
+
+func TestServerResources(t *testing.T) {
+	loader := &stubLoader{
+		reg: registry.Registry{
+			"go-lang": {Name: "go-lang", Description: "Go conventions", Content: "Handle errors. See [[commits#Message Format]]."},
+			"commits": {Name: "commits", Description: "Commit rules", Content: "## Message Format\nBe brief."},
+		},
+	}
+
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"resources/list","params":{}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"get_playbook","arguments":{"name":"go-lang"}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"resources/read","params":{"uri":"howto://playbook/commits#message-format"}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"resources/read","params":{"uri":"howto://playbook/comits"}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"resources/read","params":{"uri":"file:///commits.md"}}`,
+	}, "\n")
+	var output bytes.Buffer
+	server := NewServer(strings.NewReader(input), &output, loader, "test", log.New(io.Discard, "", 0))
+
+	if err := server.Serve(); err != nil {
+		t.Fatalf("Serve() returned error: %v", err)
+	}
+
+	messages := decodeLines(t, output.String())
+	if len(messages) != 5 {
+		t.Fatalf("expected 5 responses, got %d", len(messages))
+	}
+
+	resources, ok := messages[0].Result["resources"].([]any)
+	if !ok || len(resources) != 2 {
+		t.Fatalf("expected two resources, got %#v", messages[0].Result)
+	}
+	if first := resources[0].(map[string]any); !strings.HasPrefix(first["uri"].(string), "howto://playbook/") {
+		t.Errorf("expected howto:// resource URIs, got %v", first["uri"])
+	}
+
+	verifyContentContains(t, messages[1].Result, "See [commits § Message Format](howto://playbook/commits#message-format).")
+
+	contents, ok := messages[2].Result["contents"].([]any)
+	if !ok || len(contents) != 1 {
+		t.Fatalf("expected the linked playbook, got %#v", messages[2].Result)
+	}
+	if text := contents[0].(map[string]any)["text"]; text != "## Message Format\nBe brief." {
+		t.Errorf("expected the commits playbook, got %q", text)
+	}
+
+	if messages[3].Error == nil || messages[3].Error.Code != codeResourceNotFound || !strings.Contains(messages[3].Error.Message, `"commits"`) {
+		t.Errorf("expected resource not found with a suggestion, got %+v", messages[3].Error)
+	}
+	if messages[4].Error == nil || messages[4].Error.Code != codeInvalidParams {
+		t.Errorf("expected invalid params for a foreign URI, got %+v", messages[4].Error)
+	}
+}
+
+func TestServerXMLFormat(t *testing.T) {
+	loader := &stubLoader{
+		reg: registry.Registry{
+			"go-lang": {Name: "go-lang", Description: "Go \"conventions\"", Content: "Wrap errors & never write </playbook>."},
+			"commits": {Name: "commits", Description: "Commit rules", Content: "Be brief."},
+		},
+		bundles: config.Bundles{"backend": {"go-lang", "commits"}},
+	}
+
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"get_playbook","arguments":{"name":"go-lang","format":"xml"}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"get_playbook","arguments":{"name":"@backend","format":"xml"}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"list_playbooks","arguments":{"format":"xml"}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"get_playbook","arguments":{"name":"go-lang","format":"html"}}}`,
+	}, "\n")
+	var output bytes.Buffer
+	server := NewServer(strings.NewReader(input), &output, loader, "test", log.New(io.Discard, "", 0))
+
+	if err := server.Serve(); err != nil {
+		t.Fatalf("Serve() returned error: %v", err)
+	}
+
+	messages := decodeLines(t, output.String())
+	if len(messages) != 4 || messages[0].Error != nil || messages[1].Error != nil || messages[2].Error != nil {
+		t.Fatalf("expected successful XML responses, got %+v", messages)
+	}
+	verifyContentContains(t, messages[0].Result, `<playbook name="go-lang" source="global"`)
+	verifyContentContains(t, messages[0].Result, "Wrap errors &amp; never write &lt;/playbook&gt;.\n</playbook>")
+	verifyContentContains(t, messages[1].Result, `<playbooks bundle="@backend"`)
+	verifyContentContains(t, messages[2].Result, `<playbook name="go-lang" description="Go &quot;conventions&quot;"`)
+	verifyContentContains(t, messages[2].Result, `<bundle name="@backend" members="go-lang, commits" />`)
+
+	if messages[3].Error == nil || messages[3].Error.Code != codeInvalidParams {
+		t.Fatalf("expected invalid params error for unknown format, got %+v", messages[3].Error)
+	}
+}
+
+func TestServerMaxTokens(t *testing.T) {
+	content := "Deploy carefully.\n\n## Rollback\n" + strings.Repeat("Revert the release and restore the database snapshot.\n", 20)
+	loader := &stubLoader{
+		reg: registry.Registry{
+			"deploy": {Name: "deploy", Description: "Deploys", Content: content},
+		},
+	}
+
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"get_playbook","arguments":{"name":"deploy","max_tokens":100}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"list_playbooks","arguments":{}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"get_playbook","arguments":{"name":"deploy","max_tokens":0}}}`,
+	}, "\n")
+	var output bytes.Buffer
+	server := NewServer(strings.NewReader(input), &output, loader, "test", log.New(io.Discard, "", 0))
+
+	if err := server.Serve(); err != nil {
+		t.Fatalf("Serve() returned error: %v", err)
+	}
+
+	messages := decodeLines(t, output.String())
+	if len(messages) != 3 || messages[0].Error != nil || messages[1].Error != nil {
+		t.Fatalf("expected successful responses, got %+v", messages)
+	}
+	verifyContentContains(t, messages[0].Result, "Deploy carefully.\n\n> **Trimmed to fit the token budget:** left out 1 of 2 sections")
+	metadata := messages[0].Result["metadata"].(map[string]any)
+	if omitted, ok := metadata["omitted_sections"].([]any); !ok || len(omitted) != 1 || omitted[0] != "Rollback" {
+		t.Fatalf("expected the omitted section in metadata, got %v", metadata["omitted_sections"])
+	}
+	if tokens, ok := metadata["tokens"].(float64); !ok || tokens <= 100 {
+		t.Fatalf("expected the full token estimate in metadata, got %v", metadata["tokens"])
+	}
+
+	verifyContentContains(t, messages[1].Result, "- deploy — Deploys (~")
+
+	if messages[2].Error == nil || messages[2].Error.Code != codeInvalidParams {
+		t.Fatalf("expected invalid params error for max_tokens 0, got %+v", messages[2].Error)
+	}
+}
+
+func TestServerGetPlaybookDeprecated(t *testing.T) {
+	loader := &stubLoader{
+		reg: registry.Registry{
+			"go-lang": {Name: "go-lang", Description: "Go conventions", Content: "New rules."},
+			"golang":  {Name: "golang", Description: "Old Go", Content: "Old rules.", Deprecated: true, ReplacedBy: "go-lang"},
+		},
+	}
+
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"get_playbook","arguments":{"name":"golang"}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"list_playbooks","arguments":{}}}`,
+	}, "\n")
+	var output bytes.Buffer
+	server := NewServer(strings.NewReader(input), &output, loader, "test", log.New(io.Discard, "", 0))
+
+	if err := server.Serve(); err != nil {
+		t.Fatalf("Serve() returned error: %v", err)
+	}
+
+	messages := decodeLines(t, output.String())
+	if len(messages) != 2 || messages[0].Error != nil {
+		t.Fatalf("expected a successful response, got %+v", messages)
+	}
+	verifyContentContains(t, messages[0].Result, "**Deprecated:** the `golang` playbook has been replaced by `go-lang`")
+	verifyContentContains(t, messages[0].Result, "New rules.")
+
+	metadata := messages[0].Result["metadata"].(map[string]any)
+	redirect, ok := metadata["redirect"].(map[string]any)
+	if metadata["deprecated"] != true || !ok || redirect["from"] != "golang" || redirect["to"] != "go-lang" {
+		t.Fatalf("expected redirect metadata, got %v", metadata)
+	}
+
+	if text := messages[1].Result["content"].([]any)[0].(map[string]any)["text"].(string); strings.Contains(text, "golang") {
+		t.Fatalf("expected deprecated playbook to be hidden from the listing, got %q", text)
+	}
+}
+
+func TestServerGetPlaybookReportsReviewDate(t *testing.T) {
+	loader := &stubLoader{
+		reg: registry.Registry{
+			"deploy": {Name: "deploy", Description: "Deploy", Content: "Ship it.", Owners: []string{"@ops"}, ReviewBy: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"get_playbook","arguments":{"name":"deploy"}}}`
+	var output bytes.Buffer
+	server := NewServer(strings.NewReader(input), &output, loader, "test", log.New(io.Discard, "", 0))
+
+	if err := server.Serve(); err != nil {
+		t.Fatalf("Serve() returned error: %v", err)
+	}
+
+	messages := decodeLines(t, output.String())
+	if len(messages) != 1 || messages[0].Error != nil {
+		t.Fatalf("expected a successful response, got %+v", messages)
+	}
+	metadata := messages[0].Result["metadata"].(map[string]any)
+	if metadata["review_by"] != "2024-03-01" || metadata["overdue"] != true {
+		t.Fatalf("expected overdue review date in metadata, got %v", metadata)
+	}
+	if owners, ok := metadata["owners"].([]any); !ok || len(owners) != 1 || owners[0] != "@ops" {
+		t.Fatalf("expected owners in metadata, got %v", metadata["owners"])
+	}
+}
+
+func TestServerPassesProfileArgument(t *testing.T) {
+	loader := &stubLoader{
+		reg: registry.Registry{
+			"release-checklist": {Name: "release-checklist", Description: "Release steps.", Content: "Tag it."},
+		},
+	}
+
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"list_playbooks","arguments":{"profile":"release"}}}`
+	var output bytes.Buffer
+	server := NewServer(strings.NewReader(input), &output, loader, "test", log.New(io.Discard, "", 0))
+
+	if err := server.Serve(); err != nil {
+		t.Fatalf("Serve() returned error: %v", err)
+	}
+
+	messages := decodeLines(t, output.String())
+	if len(messages) != 1 || messages[0].Error != nil {
+		t.Fatalf("expected one successful response, got %+v", messages)
+	}
+	if loader.lastOpts.Profile != "release" {
+		t.Fatalf("expected profile release to reach the loader, got %q", loader.lastOpts.Profile)
+	}
+}
+
+func TestServerRejectsUnknownProfile(t *testing.T) {
+	loader := &stubLoader{err: &config.UnknownProfileError{Name: "relase", Available: []string{"release", "review"}}}
+
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"get_playbook","arguments":{"name":"commits","profile":"relase"}}}`
+	var output bytes.Buffer
+	server := NewServer(strings.NewReader(input), &output, loader, "test", log.New(io.Discard, "", 0))
+
+	if err := server.Serve(); err != nil {
+		t.Fatalf("Serve() returned error: %v", err)
+	}
+
+	messages := decodeLines(t, output.String())
+	if len(messages) != 1 || messages[0].Error == nil || messages[0].Error.Code != codeInvalidParams {
+		t.Fatalf("expected invalid params error for an unknown profile, got %+v", messages)
+	}
+	if !strings.Contains(messages[0].Error.Message, "available: release, review") {
+		t.Errorf("expected the available profiles in the message, got %q", messages[0].Error.Message)
+	}
+	var data struct {
+		Profile   string   `json:"profile"`
+		Available []string `json:"available"`
+	}
+	if err := json.Unmarshal(messages[0].Error.Data, &data); err != nil || data.Profile != "relase" || len(data.Available) != 2 {
+		t.Errorf("expected the profile and available profiles in the error data, got %s", messages[0].Error.Data)
+	}
+}
+
+func TestServerRejectsProfileOnUntrustedProject(t *testing.T) {
+	loader := &stubLoader{err: &app.UntrustedProfileError{Profile: "release", Trust: app.TrustUntrusted}}
+
+	input := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"list_playbooks","arguments":{"profile":"release"}}}`
+	var output bytes.Buffer
+	server := NewServer(strings.NewReader(input), &output, loader, "test", log.New(io.Discard, "", 0))
+
+	if err := server.Serve(); err != nil {
+		t.Fatalf("Serve() returned error: %v", err)
+	}
+
+	messages := decodeLines(t, output.String())
+	if len(messages) != 1 || messages[0].Error == nil || messages[0].Error.Code != codeInvalidParams {
+		t.Fatalf("expected invalid params error for a profile on an untrusted project, got %+v", messages)
+	}
+	if !strings.Contains(messages[0].Error.Message, "howto trust") || !strings.Contains(string(messages[0].Error.Data), `"trust":"untrusted"`) {
+		t.Errorf("expected the error to point at `howto trust`, got %q (data %s)", messages[0].Error.Message, messages[0].Error.Data)
+	}
+}
+
+func TestServerUsesClientInfoAsAgent(t *testing.T) {
+	loader := &stubLoader{reg: registry.Registry{}}
+
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"clientInfo":{"name":"runner-agent"}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"list_playbooks","arguments":{}}}`,
+	}, "\n")
+	var output bytes.Buffer
+	server := NewServer(strings.NewReader(input), &output, loader, "test", log.New(io.Discard, "", 0))
+
+	if err := server.Serve(); err != nil {
+		t.Fatalf("Serve() returned error: %v", err)
+	}
+
+	if loader.lastOpts.Agent != "runner-agent" {
+		t.Fatalf("expected client name to reach the loader as agent, got %q", loader.lastOpts.Agent)
+	}
+}
+
+func TestServerSearchPlaybooks(t *testing.T) {
+	loader := &stubLoader{
+		reg: registry.Registry{
+			"db-migrations": {Name: "db-migrations", Description: "Database migrations", Content: "Never edit an applied migration."},
+			"go-lang":       {Name: "go-lang", Description: "Go conventions", Content: "Handle errors."},
+		},
+	}
+
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"search_playbooks","arguments":{"query":"migration","limit":5}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"search_playbooks","arguments":{}}}`,
+	}, "\n")
+	var output bytes.Buffer
+	server := NewServer(strings.NewReader(input), &output, loader, "test", log.New(io.Discard, "", 0))
+
+	if err := server.Serve(); err != nil {
+		t.Fatalf("Serve() returned error: %v", err)
+	}
+
+	messages := decodeLines(t, output.String())
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 responses, got %d", len(messages))
+	}
+	if messages[0].Error != nil {
+		t.Fatalf("search_playbooks returned error: %+v", messages[0].Error)
+	}
+	verifyContentContains(t, messages[0].Result, "db-migrations")
+	verifyContentContains(t, messages[0].Result, "1: Never edit an applied migration.")
+
+	if messages[1].Error == nil || messages[1].Error.Code != codeInvalidParams {
+		t.Fatalf("expected invalid params error for missing query, got %+v", messages[1].Error)
+	}
+}
+
 type stubLoader struct {
-	mu  sync.Mutex
-	reg registry.Registry
-	err error
+	mu         sync.Mutex
+	reg        registry.Registry
+	err        error
+	lastOpts   app.LoadOptions
+	rules      instructions.Set
+	provenance registry.Provenance
+	bundles    config.Bundles
 }
 
-func (s *stubLoader) Load() (registry.Registry, error) {
+func (s *stubLoader) Load(opts app.LoadOptions) (*app.Catalog, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	s.lastOpts = opts
 	if s.err != nil {
 		return nil, s.err
 	}
 
-	copy := make(registry.Registry, len(s.reg))
-	for k, v := range s.reg {
-		copy[k] = v
-	}
-	return copy, nil
+	snapshot := registry.NewSnapshot(s.reg).WithBundles(s.bundles)
+	return &app.Catalog{
+		Registry:   snapshot,
+		Provenance: s.provenance,
+		Trust:      app.TrustNone,
+		Rules:      s.rules,
+		Index:      search.NewIndex(snapshot.GetAll()),
+	}, nil
 }
 
 type message struct {
@@ -132,8 +619,9 @@ type message struct {
 }
 
 type messageError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
 }
 
 func decodeLines(t *testing.T, raw string) []message {
@@ -0,0 +1,201 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/howto/internal/parser"
+	"github.com/yourusername/howto/internal/tokens"
+	"github.com/yourusername/howto/internal/xref"
+)
+
+// introduction names the text before a playbook's first heading in trim notices
+const introduction = "(introduction)"
+
+// BudgetError reports a token budget too small for even the trim notice of the first playbook fetched.
+type BudgetError struct {
+	MaxTokens int    // The budget asked for
+	Name      string // The first playbook fetched
+	Needed    int    // Tokens the smallest trimmed output needs
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("a budget of %d tokens is too small for %q: trimmed to its notice it still needs %d tokens", e.MaxTokens, e.Name, e.Needed)
+}
+
+// FitContent trims the content of a single playbook to at most maxTokens tokens
+// by leaving out whole sections (see xref.Sections) from the end, and appends a
+// TrimNotice saying what was left out. Content that already fits is returned
+// unchanged. omitted lists the headings of the sections left out. When not even
+// the notice fits, a *BudgetError is returned. A maxTokens of 0 means no limit.
+func FitContent(name, content string, maxTokens int) (fitted string, omitted []string, err error) {
+	if maxTokens <= 0 {
+		return content, nil, nil
+	}
+	contents, omissions, err := fit([]string{name}, []string{content}, maxTokens, func(contents []string) string {
+		return contents[0]
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return contents[0], omissions[0], nil
+}
+
+// FitDocuments returns copies of docs trimmed so that FormatPlaybooks of them
+// takes at most maxTokens tokens. Earlier playbooks are served in full first and
+// later ones get what is left of the budget (see FitContent). Playbooks that do
+// not fit even trimmed are left out, together with every playbook after them, and
+// named in a DroppedNotice at the end of the last one served. A maxTokens of 0 means no limit.
+func FitDocuments(docs []parser.Document, maxTokens int) ([]parser.Document, error) {
+	out, _, err := fitDocuments(docs, maxTokens, FormatPlaybooks)
+	return out, err
+}
+
+// FitPlaybooks trims fetched playbooks like FitDocuments, recording the headings
+// of the sections left out of each. The budget covers the Markdown text of the
+// playbooks; JSON and XML framing comes on top.
+func FitPlaybooks(playbooks []Playbook, maxTokens int) ([]Playbook, error) {
+	docs := make([]parser.Document, len(playbooks))
+	for i, playbook := range playbooks {
+		docs[i] = parser.Document{Name: playbook.Name, Version: playbook.Version, Content: playbook.Content}
+	}
+
+	fitted, omitted, err := fitDocuments(docs, maxTokens, FormatPlaybooks)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Playbook, len(fitted))
+	for i := range fitted {
+		out[i] = playbooks[i]
+		out[i].Content = fitted[i].Content
+		if omitted != nil {
+			out[i].OmittedSections = omitted[i]
+		}
+	}
+	return out, nil
+}
+
+// fitDocuments trims docs so that render of them takes at most maxTokens tokens (see FitDocuments)
+func fitDocuments(docs []parser.Document, maxTokens int, render func([]parser.Document) string) ([]parser.Document, [][]string, error) {
+	out := make([]parser.Document, len(docs))
+	copy(out, docs)
+	if maxTokens <= 0 || len(docs) == 0 {
+		return out, nil, nil
+	}
+
+	names := make([]string, len(docs))
+	contents := make([]string, len(docs))
+	for i, doc := range docs {
+		names[i], contents[i] = doc.Name, doc.Content
+	}
+
+	contents, omitted, err := fit(names, contents, maxTokens, func(contents []string) string {
+		trial := make([]parser.Document, len(contents))
+		copy(trial, out)
+		for i, content := range contents {
+			trial[i].Content = content
+		}
+		return render(trial)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	out = out[:len(contents)]
+	for i, content := range contents {
+		out[i].Content = content
+	}
+	return out, omitted, nil
+}
+
+// fit trims contents in order so that render of the kept contents takes at most
+// maxTokens tokens. Each candidate is tried with a DroppedNotice for the
+// playbooks after it appended, so the output still fits if it turns out to be
+// the last one served.
+func fit(names, contents []string, maxTokens int, render func([]string) string) ([]string, [][]string, error) {
+	var kept []string
+	var omitted [][]string
+	for i, content := range contents {
+		trailer := ""
+		if rest := names[i+1:]; len(rest) > 0 {
+			trailer = "\n\n" + DroppedNotice(rest, len(names))
+		}
+		fits := func(candidate string) bool {
+			return tokens.Estimate(render(append(kept[:i:i], candidate+trailer))) <= maxTokens
+		}
+
+		fitted, left, ok := fitContent(content, fits)
+		if !ok {
+			if i == 0 {
+				return nil, nil, &BudgetError{MaxTokens: maxTokens, Name: names[0], Needed: tokens.Estimate(render([]string{fitted + trailer}))}
+			}
+			kept[i-1] += "\n\n" + DroppedNotice(names[i:], len(names))
+			break
+		}
+		kept = append(kept, fitted)
+		omitted = append(omitted, left)
+	}
+	return kept, omitted, nil
+}
+
+// fitContent leaves out sections from the end of content until fits accepts it,
+// appending a TrimNotice. When nothing fits, ok is false and fitted is the
+// smallest form tried: the notice alone.
+func fitContent(content string, fits func(string) bool) (fitted string, omitted []string, ok bool) {
+	if fits(content) {
+		return content, nil, true
+	}
+
+	total := tokens.Estimate(content)
+	sections := xref.Sections(content)
+	for keep := len(sections) - 1; keep >= 0; keep-- {
+		var kept strings.Builder
+		for _, section := range sections[:keep] {
+			kept.WriteString(section.Text)
+		}
+
+		omitted = omitted[:0]
+		for _, section := range sections[keep:] {
+			heading := section.Heading
+			if heading == "" {
+				heading = introduction
+			}
+			omitted = append(omitted, heading)
+		}
+
+		body := strings.TrimSpace(kept.String())
+		fitted = TrimNotice(omitted, len(sections), total-tokens.Estimate(body))
+		if body != "" {
+			fitted = body + "\n\n" + fitted
+		}
+		if fits(fitted) {
+			return fitted, omitted, true
+		}
+	}
+	return fitted, nil, false
+}
+
+// TrimNotice formats the note appended to a playbook trimmed to fit a token budget.
+func TrimNotice(omitted []string, sections, omittedTokens int) string {
+	return fmt.Sprintf("> **Trimmed to fit the token budget:** left out %d of %d sections (about %d tokens): %s. Fetch this playbook without a token limit to read them.",
+		len(omitted), sections, omittedTokens, quoteAll(omitted))
+}
+
+// DroppedNotice formats the note appended to the last playbook served when later ones did not fit the token budget.
+func DroppedNotice(dropped []string, playbooks int) string {
+	return fmt.Sprintf("> **Trimmed to fit the token budget:** left out %d of %d playbooks: %s. Fetch them separately to read them.",
+		len(dropped), playbooks, quoteAll(dropped))
+}
+
+func quoteAll(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = fmt.Sprintf("%q", value)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// FormatTokens renders a token estimate for listings
+func FormatTokens(count int) string {
+	return fmt.Sprintf("~%d tokens", count)
+}
@@ -0,0 +1,157 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/howto/internal/parser"
+	"github.com/yourusername/howto/internal/registry"
+	"github.com/yourusername/howto/internal/tokens"
+)
+
+var budgetContent = "Deploy carefully.\n\n## Checklist\nRun the migrations, then check the dashboards.\n\n## Rollback\n" +
+	strings.Repeat("Revert the release, restore the database snapshot, announce the incident and page the owner on call.\n", 5)
+
+func TestFitContent(t *testing.T) {
+	full := tokens.Estimate(budgetContent)
+	if fitted, omitted, err := FitContent("deploy", budgetContent, full); err != nil || fitted != budgetContent || omitted != nil {
+		t.Fatalf("expected content that fits to be unchanged, got %q (omitted %v, err %v)", fitted, omitted, err)
+	}
+
+	fitted, omitted, err := FitContent("deploy", budgetContent, full-1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(omitted, []string{"Rollback"}) {
+		t.Fatalf("expected the last section to be left out, got %v", omitted)
+	}
+	if !strings.HasPrefix(fitted, "Deploy carefully.\n\n## Checklist\nRun the migrations") || strings.Contains(fitted, "Revert") {
+		t.Errorf("expected whole sections to be kept, got %q", fitted)
+	}
+	if !strings.Contains(fitted, `left out 1 of 3 sections`) || !strings.Contains(fitted, `"Rollback"`) {
+		t.Errorf("expected a notice naming the omitted section, got %q", fitted)
+	}
+	if tokens.Estimate(fitted) > full-1 {
+		t.Errorf("expected the trimmed content to fit, got %d tokens", tokens.Estimate(fitted))
+	}
+
+}
+
+func TestFitContentRejectsBudgetsBelowTheNotice(t *testing.T) {
+	_, _, err := FitContent("deploy", budgetContent, 5)
+	var budgetErr *BudgetError
+	if !errors.As(err, &budgetErr) || budgetErr.Name != "deploy" || budgetErr.Needed <= 5 {
+		t.Fatalf("expected a budget error naming the playbook, got %v", err)
+	}
+
+	if fitted, _, err := FitContent("short", "Be brief.", tokens.Estimate("Be brief.")); err != nil || fitted != "Be brief." {
+		t.Errorf("expected a playbook within the budget to be served whole, got %q (err %v)", fitted, err)
+	}
+	if _, _, err := FitContent("short", "Be brief and write clear commit messages.", tokens.Estimate("Be brief.")); err == nil {
+		t.Error("expected an error rather than a playbook larger than the budget")
+	}
+}
+
+func TestFitDocumentsSharesBudget(t *testing.T) {
+	docs := []parser.Document{
+		{Name: "first", Content: budgetContent},
+		{Name: "second", Content: budgetContent},
+	}
+
+	maxTokens := tokens.Estimate(FormatPlaybooks(docs[:1])) + 100
+	fitted, err := FitDocuments(docs, maxTokens)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fitted) != 2 || fitted[0].Content != budgetContent {
+		t.Fatalf("expected the first playbook to be served in full, got %+v", fitted)
+	}
+	if !strings.Contains(fitted[1].Content, "> **Trimmed") {
+		t.Errorf("expected the second playbook to get what is left of the budget, got %q", fitted[1].Content)
+	}
+	if docs[1].Content != budgetContent {
+		t.Errorf("expected the input documents to be left alone")
+	}
+	if got := tokens.Estimate(FormatPlaybooks(fitted)); got > maxTokens {
+		t.Errorf("expected at most %d tokens, got %d", maxTokens, got)
+	}
+
+	if unlimited, err := FitDocuments(docs, 0); err != nil || !reflect.DeepEqual(unlimited, docs) {
+		t.Errorf("expected no limit for 0, got %+v (err %v)", unlimited, err)
+	}
+}
+
+func TestFitDocumentsDropsPlaybooksThatDoNotFit(t *testing.T) {
+	var docs []parser.Document
+	for _, name := range []string{"first", "second", "third", "fourth"} {
+		docs = append(docs, parser.Document{Name: name, Content: budgetContent})
+	}
+
+	for maxTokens := 40; maxTokens <= 4*tokens.Estimate(budgetContent); maxTokens += 7 {
+		fitted, err := FitDocuments(docs, maxTokens)
+		if err != nil {
+			var budgetErr *BudgetError
+			if !errors.As(err, &budgetErr) {
+				t.Fatalf("unexpected error for %d tokens: %v", maxTokens, err)
+			}
+			continue
+		}
+		out := FormatPlaybooks(fitted)
+		if got := tokens.Estimate(out); got > maxTokens {
+			t.Fatalf("expected at most %d tokens, got %d:\n%s", maxTokens, got, out)
+		}
+		if dropped := len(docs) - len(fitted); dropped > 0 && !strings.Contains(out, fmt.Sprintf("left out %d of 4 playbooks", dropped)) {
+			t.Errorf("expected one notice naming the %d playbooks left out, got:\n%s", dropped, out)
+		}
+	}
+}
+
+func TestPrintPlaybooksMaxTokens(t *testing.T) {
+	reg := registry.Registry{
+		"deploy": {Name: "deploy", Description: "Deploys", Content: budgetContent},
+		"tiny":   {Name: "tiny", Description: "Tiny", Version: "1.0.0", Content: strings.Repeat("Keep it short. ", 10)},
+	}
+	snapshot := registry.NewSnapshot(reg)
+
+	var buf bytes.Buffer
+	PrintHelp(&buf, snapshot, nil)
+	if !strings.Contains(buf.String(), "  deploy: Deploys (~") {
+		t.Errorf("expected a token estimate in the listing, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := PrintPlaybooks(&buf, snapshot, []string{"deploy"}, tokens.Estimate(budgetContent)-1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(buf.String(), "Revert") || !strings.Contains(buf.String(), `"Rollback"`) {
+		t.Errorf("expected the Rollback section to be left out, got:\n%s", buf.String())
+	}
+
+	for _, names := range [][]string{{"tiny"}, {"deploy", "tiny"}, {"tiny", "deploy", "tiny"}} {
+		for maxTokens := 1; maxTokens <= 150; maxTokens++ {
+			buf.Reset()
+			if err := PrintPlaybooks(&buf, snapshot, names, maxTokens); err != nil {
+				continue
+			}
+			if got := tokens.Estimate(buf.String()); got > maxTokens {
+				t.Fatalf("%v with --max-tokens %d printed %d tokens:\n%s", names, maxTokens, got, buf.String())
+			}
+		}
+	}
+
+	buf.Reset()
+	if err := PrintJSONPlaybooks(&buf, snapshot, []string{"deploy"}, "", 60); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"omitted_sections": [`) || !strings.Contains(buf.String(), `"tokens": `) {
+		t.Errorf("expected omitted sections and a token estimate, got:\n%s", buf.String())
+	}
+
+	if err := PrintJSONPlaybooks(&buf, snapshot, []string{"deploy"}, "", 1); err == nil {
+		t.Error("expected an error for a budget below the trim notice")
+	}
+}
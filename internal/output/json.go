@@ -0,0 +1,324 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/yourusername/howto/internal/parser"
+	"github.com/yourusername/howto/internal/registry"
+	"github.com/yourusername/howto/internal/tokens"
+	"github.com/yourusername/howto/internal/xref"
+)
+
+// SchemaVersion identifies the shape of the JSON objects below. It changes only
+// when a field is removed or changes meaning; new optional fields may be added
+// without a bump, so consumers should ignore fields they do not know.
+const SchemaVersion = 1
+
+// Catalogue is the JSON form of the playbook listing (`howto --format json`).
+type Catalogue struct {
+	SchemaVersion int              `json:"schema_version"`
+	Revision      string           `json:"revision"`
+	Trust         string           `json:"trust,omitempty"`
+	Rules         []string         `json:"rules"`
+	Playbooks     []CatalogueEntry `json:"playbooks"`
+	Bundles       []BundleEntry    `json:"bundles"`
+	Diagnostics   []DiagnosticJSON `json:"diagnostics"`
+}
+
+// CatalogueEntry describes one listed playbook without its content.
+type CatalogueEntry struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Source      string   `json:"source"`
+	Path        string   `json:"path"`
+	Required    bool     `json:"required"`
+	Tags        []string `json:"tags"`
+	Priority    string   `json:"priority"`
+	Order       int      `json:"order,omitempty"`
+	Version     string   `json:"version,omitempty"`
+	Hash        string   `json:"hash"`
+	Tokens      int      `json:"tokens"` // Estimated size of the full content (see tokens.Estimate)
+}
+
+// BundleEntry lists the members of a bundle, in the order they are served.
+type BundleEntry struct {
+	Name    string   `json:"name"`
+	Members []string `json:"members"`
+}
+
+// DiagnosticJSON is a problem found while building the registry.
+type DiagnosticJSON struct {
+	Severity string `json:"severity"`
+	Name     string `json:"name,omitempty"`
+	Path     string `json:"path,omitempty"`
+	Message  string `json:"message"`
+}
+
+// Playbook is the JSON form of a fetched playbook (`howto --format json <playbook>`).
+type Playbook struct {
+	PlaybookInfo
+	Content string `json:"content"`
+}
+
+// PlaybookInfo is everything about a fetched playbook except its content: the
+// catalogue entry plus what an agent needs to act on it. `get_playbook` returns
+// it as metadata next to the content.
+type PlaybookInfo struct {
+	SchemaVersion int `json:"schema_version"`
+	CatalogueEntry
+	Revision        string          `json:"revision"`
+	Trust           string          `json:"trust,omitempty"`
+	Agents          []string        `json:"agents,omitempty"`
+	Merge           string          `json:"merge,omitempty"`
+	Owners          []string        `json:"owners,omitempty"`
+	ReviewBy        string          `json:"review_by,omitempty"`
+	Overdue         bool            `json:"overdue,omitempty"` // review_by has passed (see `howto stale`)
+	Deprecated      bool            `json:"deprecated,omitempty"`
+	DeprecationNote string          `json:"deprecation_note,omitempty"`
+	Redirect        *Redirect       `json:"redirect,omitempty"`
+	Provenance      []CandidateJSON `json:"provenance,omitempty"`
+	OmittedSections []string        `json:"omitted_sections,omitempty"` // Left out to fit a token budget (see FitPlaybooks)
+}
+
+// Redirect points from a deprecated playbook to the one served in its place.
+type Redirect struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Hash string `json:"hash"`
+}
+
+// CandidateJSON is one document considered for a playbook name (see registry.Candidate).
+type CandidateJSON struct {
+	Source  string `json:"source"`
+	Path    string `json:"path"`
+	Version string `json:"version,omitempty"`
+	Outcome string `json:"outcome"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+// Bundle is the JSON form of a fetched bundle, or of several playbooks fetched at once (without a name).
+type Bundle struct {
+	SchemaVersion int        `json:"schema_version"`
+	Name          string     `json:"name,omitempty"`
+	Revision      string     `json:"revision"`
+	Trust         string     `json:"trust,omitempty"`
+	Playbooks     []Playbook `json:"playbooks"`
+}
+
+// ErrorJSON wraps a failure so scripts can tell it apart from a result.
+type ErrorJSON struct {
+	SchemaVersion int         `json:"schema_version"`
+	Error         ErrorDetail `json:"error"`
+}
+
+// ErrorDetail describes a failure. Code is "unknown_playbook" when names do not
+// resolve, with every such name and its close matches in Unknown, and "error"
+// otherwise. Name and Suggestions repeat the first unknown name.
+type ErrorDetail struct {
+	Code        string        `json:"code"`
+	Message     string        `json:"message"`
+	Name        string        `json:"name,omitempty"`
+	Suggestions []string      `json:"suggestions,omitempty"`
+	Unknown     []UnknownName `json:"unknown,omitempty"`
+}
+
+// UnknownName is a requested name that did not resolve.
+type UnknownName struct {
+	Name        string   `json:"name"`
+	Suggestions []string `json:"suggestions"`
+}
+
+// NewCatalogue describes the listed playbooks and bundles of reg.
+func NewCatalogue(reg *registry.Snapshot, rules []string, trust string, diagnostics []registry.Diagnostic) Catalogue {
+	catalogue := Catalogue{
+		SchemaVersion: SchemaVersion,
+		Revision:      reg.Revision(),
+		Trust:         trust,
+		Rules:         append([]string{}, rules...),
+		Playbooks:     []CatalogueEntry{},
+		Bundles:       []BundleEntry{},
+		Diagnostics:   []DiagnosticJSON{},
+	}
+	for _, doc := range reg.Listed() {
+		catalogue.Playbooks = append(catalogue.Playbooks, NewCatalogueEntry(reg, doc))
+	}
+	for _, bundle := range reg.Bundles() {
+		catalogue.Bundles = append(catalogue.Bundles, BundleEntry{Name: bundle, Members: reg.BundleMembers(bundle)})
+	}
+	for _, diagnostic := range diagnostics {
+		catalogue.Diagnostics = append(catalogue.Diagnostics, DiagnosticJSON{
+			Severity: string(diagnostic.Severity),
+			Name:     diagnostic.Name,
+			Path:     diagnostic.Path,
+			Message:  diagnostic.Message,
+		})
+	}
+	return catalogue
+}
+
+// NewCatalogueEntry describes doc as it appears in listings.
+func NewCatalogueEntry(reg *registry.Snapshot, doc parser.Document) CatalogueEntry {
+	priority := doc.Priority
+	if priority == "" {
+		priority = parser.PriorityNormal
+	}
+	return CatalogueEntry{
+		Name:        doc.Name,
+		Description: doc.Description,
+		Source:      doc.Source.String(),
+		Path:        doc.FilePath,
+		Required:    doc.Required,
+		Tags:        append([]string{}, doc.Tags...),
+		Priority:    string(priority),
+		Order:       doc.Order,
+		Version:     doc.Version,
+		Hash:        reg.Hash(doc.Name),
+		Tokens:      tokens.Estimate(doc.Content),
+	}
+}
+
+// NewPlaybook describes a fetched playbook. The content has deprecations applied
+// (see ApplyDeprecations) and links rewritten for style.
+func NewPlaybook(reg *registry.Snapshot, doc parser.Document, style xref.Style) Playbook {
+	return Playbook{
+		PlaybookInfo: NewPlaybookInfo(reg, doc),
+		Content:      xref.Rewrite(ApplyDeprecations(reg, []parser.Document{doc})[0].Content, style),
+	}
+}
+
+// NewPlaybookInfo describes a fetched playbook without its content.
+func NewPlaybookInfo(reg *registry.Snapshot, doc parser.Document) PlaybookInfo {
+	info := PlaybookInfo{
+		SchemaVersion:   SchemaVersion,
+		CatalogueEntry:  NewCatalogueEntry(reg, doc),
+		Revision:        reg.Revision(),
+		Agents:          doc.Agents,
+		Owners:          doc.Owners,
+		Deprecated:      doc.Deprecated,
+		DeprecationNote: doc.DeprecationNote,
+	}
+	if doc.Merge == parser.MergeAppend || doc.Merge == parser.MergePrepend {
+		info.Merge = string(doc.Merge)
+	}
+	if !doc.ReviewBy.IsZero() {
+		info.ReviewBy = doc.ReviewBy.Format(parser.ReviewByLayout)
+		info.Overdue = info.ReviewBy < time.Now().Format(parser.ReviewByLayout)
+	}
+	if replacement, ok := reg.Replacement(doc.Name); ok {
+		info.Redirect = &Redirect{From: doc.Name, To: replacement.Name, Hash: reg.Hash(replacement.Name)}
+	}
+	return info
+}
+
+// NewCandidates describes the provenance of a playbook name.
+func NewCandidates(candidates []registry.Candidate) []CandidateJSON {
+	out := make([]CandidateJSON, 0, len(candidates))
+	for _, candidate := range candidates {
+		out = append(out, CandidateJSON{
+			Source:  candidate.Source.String(),
+			Path:    candidate.Path,
+			Version: candidate.Version,
+			Outcome: string(candidate.Outcome),
+			Reason:  candidate.Reason,
+		})
+	}
+	return out
+}
+
+// NewError describes err, carrying suggestions for unknown playbook names.
+func NewError(err error) ErrorJSON {
+	detail := ErrorDetail{Code: "error", Message: err.Error()}
+
+	var unknowns []*registry.UnknownPlaybookError
+	var several *registry.UnknownPlaybooksError
+	var single *registry.UnknownPlaybookError
+	switch {
+	case errors.As(err, &several):
+		unknowns = several.Errors
+	case errors.As(err, &single):
+		unknowns = []*registry.UnknownPlaybookError{single}
+	}
+
+	for _, unknown := range unknowns {
+		detail.Unknown = append(detail.Unknown, UnknownName{Name: unknown.Name, Suggestions: append([]string{}, unknown.Suggestions...)})
+	}
+	if len(detail.Unknown) > 0 {
+		detail.Code = "unknown_playbook"
+		detail.Name = detail.Unknown[0].Name
+		detail.Suggestions = detail.Unknown[0].Suggestions
+	}
+	return ErrorJSON{SchemaVersion: SchemaVersion, Error: detail}
+}
+
+// PrintJSONPlaybooks outputs the playbooks names refer to as JSON: a Playbook for
+// a single playbook name, otherwise a Bundle (named when names is a single bundle).
+// A positive maxTokens trims the content (see FitContent and FitPlaybooks).
+func PrintJSONPlaybooks(w io.Writer, reg *registry.Snapshot, names []string, trust string, maxTokens int) error {
+	bundle, single, err := fetch(reg, names, trust, xref.StyleCLI, maxTokens)
+	if err != nil {
+		return err
+	}
+	if single {
+		return WriteJSON(w, bundle.Playbooks[0])
+	}
+	return WriteJSON(w, bundle)
+}
+
+// fetch resolves names into a Bundle with links rewritten for style, trimmed to
+// maxTokens. single reports whether names is one playbook name, which callers
+// print on its own.
+func fetch(reg *registry.Snapshot, names []string, trust string, style xref.Style, maxTokens int) (Bundle, bool, error) {
+	docs, err := reg.ResolveAll(names)
+	if err != nil {
+		return Bundle{}, false, err
+	}
+
+	bundle := NewBundle(reg, docs, "", style)
+	bundle.Trust = trust
+	for i := range bundle.Playbooks {
+		bundle.Playbooks[i].Trust = trust
+	}
+	if len(names) == 1 && !registry.IsBundleName(names[0]) {
+		playbook := &bundle.Playbooks[0]
+		playbook.Content, playbook.OmittedSections, err = FitContent(playbook.Name, playbook.Content, maxTokens)
+		return bundle, true, err
+	}
+
+	if bundle.Playbooks, err = FitPlaybooks(bundle.Playbooks, maxTokens); err != nil {
+		return Bundle{}, false, err
+	}
+	if len(names) == 1 {
+		bundle.Name = names[0]
+	}
+	return bundle, false, nil
+}
+
+// NewBundle describes several fetched playbooks (see NewPlaybook) under a bundle name, which may be empty.
+func NewBundle(reg *registry.Snapshot, docs []parser.Document, name string, style xref.Style) Bundle {
+	bundle := Bundle{
+		SchemaVersion: SchemaVersion,
+		Name:          name,
+		Revision:      reg.Revision(),
+		Playbooks:     make([]Playbook, 0, len(docs)),
+	}
+	for _, doc := range docs {
+		bundle.Playbooks = append(bundle.Playbooks, NewPlaybook(reg, doc, style))
+	}
+	return bundle
+}
+
+// WriteJSON writes v as indented JSON followed by a newline. Markdown characters
+// such as < and & are written as-is rather than escaped.
+func WriteJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	return nil
+}
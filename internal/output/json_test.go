@@ -0,0 +1,112 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/yourusername/howto/internal/config"
+	"github.com/yourusername/howto/internal/parser"
+	"github.com/yourusername/howto/internal/registry"
+)
+
+func jsonTestSnapshot() *registry.Snapshot {
+	reg := registry.Registry{
+		"go-lang": {Name: "go-lang", Description: "Go <rules>", Required: true, Tags: []string{"go"}, Version: "2.0.0", Content: "Use [[commits]] & test.", Source: parser.SourceGlobal, FilePath: "/global/go-lang.md"},
+		"commits": {Name: "commits", Description: "Commits", Required: true, Priority: parser.PriorityHigh, Content: "Be brief.", Source: parser.SourceProjectScoped, FilePath: "/project/commits.md"},
+		"golang":  {Name: "golang", Description: "Old Go", Deprecated: true, ReplacedBy: "go-lang", Source: parser.SourceGlobal, FilePath: "/global/golang.md"},
+	}
+	return registry.NewSnapshot(reg).WithBundles(config.Bundles{"backend": {"go-lang", "commits"}})
+}
+
+func TestNewCatalogue(t *testing.T) {
+	snapshot := jsonTestSnapshot()
+	diagnostics := []registry.Diagnostic{{Severity: registry.SeverityWarning, Name: "x", Message: "broken"}}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, NewCatalogue(snapshot, []string{"rule"}, "trusted", diagnostics)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var catalogue Catalogue
+	if err := json.Unmarshal(buf.Bytes(), &catalogue); err != nil {
+		t.Fatalf("expected valid JSON, got %v:\n%s", err, buf.String())
+	}
+	if catalogue.SchemaVersion != SchemaVersion || catalogue.Revision != snapshot.Revision() || catalogue.Trust != "trusted" {
+		t.Errorf("unexpected catalogue header: %+v", catalogue)
+	}
+	if len(catalogue.Playbooks) != 2 || catalogue.Playbooks[0].Name != "commits" || catalogue.Playbooks[1].Name != "go-lang" {
+		t.Fatalf("expected listed playbooks in List order without deprecated ones, got %+v", catalogue.Playbooks)
+	}
+
+	entry := catalogue.Playbooks[1]
+	if entry.Source != "global" || entry.Path != "/global/go-lang.md" || !entry.Required || len(entry.Tags) != 1 || entry.Version != "2.0.0" || entry.Hash != snapshot.Hash("go-lang") || entry.Priority != "normal" {
+		t.Errorf("unexpected catalogue entry: %+v", entry)
+	}
+	if len(catalogue.Bundles) != 1 || catalogue.Bundles[0].Name != "backend" || len(catalogue.Diagnostics) != 1 {
+		t.Errorf("expected bundles and diagnostics, got %+v", catalogue)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("Go <rules>")) {
+		t.Errorf("expected Markdown characters not to be escaped, got %s", buf.String())
+	}
+}
+
+func TestPrintJSONPlaybook(t *testing.T) {
+	snapshot := jsonTestSnapshot()
+
+	var buf bytes.Buffer
+	if err := PrintJSONPlaybooks(&buf, snapshot, []string{"go-lang"}, "trusted", 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var playbook Playbook
+	if err := json.Unmarshal(buf.Bytes(), &playbook); err != nil {
+		t.Fatalf("expected valid JSON, got %v", err)
+	}
+	if playbook.Name != "go-lang" || playbook.Content != "Use commits (`howto commits`) & test." || playbook.Revision == "" || playbook.Trust != "trusted" {
+		t.Errorf("unexpected playbook: %+v", playbook)
+	}
+
+	buf.Reset()
+	if err := PrintJSONPlaybooks(&buf, snapshot, []string{"golang"}, "", 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	playbook = Playbook{}
+	if err := json.Unmarshal(buf.Bytes(), &playbook); err != nil {
+		t.Fatalf("expected valid JSON, got %v", err)
+	}
+	if !playbook.Deprecated || playbook.Redirect == nil || playbook.Redirect.To != "go-lang" {
+		t.Errorf("expected a redirect for the deprecated playbook, got %+v", playbook)
+	}
+
+	buf.Reset()
+	if err := PrintJSONPlaybooks(&buf, snapshot, []string{"@backend"}, "", 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var bundle Bundle
+	if err := json.Unmarshal(buf.Bytes(), &bundle); err != nil {
+		t.Fatalf("expected valid JSON, got %v", err)
+	}
+	if bundle.Name != "@backend" || len(bundle.Playbooks) != 2 || bundle.Playbooks[1].Content != "Be brief." {
+		t.Errorf("unexpected bundle: %+v", bundle)
+	}
+}
+
+func TestNewError(t *testing.T) {
+	_, err := jsonTestSnapshot().Lookup("go-lan")
+	unknown := NewError(err)
+	if unknown.Error.Code != "unknown_playbook" || unknown.Error.Name != "go-lan" || len(unknown.Error.Suggestions) == 0 || unknown.Error.Suggestions[0] != "go-lang" {
+		t.Errorf("unexpected unknown playbook error: %+v", unknown)
+	}
+
+	_, err = jsonTestSnapshot().ResolveAll([]string{"go-lan", "comits"})
+	several := NewError(err)
+	if several.Error.Code != "unknown_playbook" || len(several.Error.Unknown) != 2 || several.Error.Unknown[1].Name != "comits" {
+		t.Errorf("expected every unknown name, got %+v", several)
+	}
+
+	other := NewError(fmt.Errorf("boom"))
+	if other.Error.Code != "error" || other.Error.Message != "boom" || other.SchemaVersion != SchemaVersion {
+		t.Errorf("unexpected generic error: %+v", other)
+	}
+}
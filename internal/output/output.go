@@ -5,24 +5,49 @@ import (
 	"io"
 	"strings"
 
-	"github.com/yourusername/howto/internal/instructions"
+	"github.com/yourusername/howto/internal/parser"
 	"github.com/yourusername/howto/internal/registry"
+	"github.com/yourusername/howto/internal/search"
+	"github.com/yourusername/howto/internal/tokens"
+	"github.com/yourusername/howto/internal/xref"
 )
 
-// PrintHelp outputs the help text listing all available playbooks
-func PrintHelp(w io.Writer, reg registry.Registry) {
-	fmt.Fprintln(w, "Usage: howto [PLAYBOOK]")
+// helpCommands are the subcommands listed by PrintHelp, with their arguments
+var helpCommands = [][2]string{
+	{"search <query>", "find playbooks by topic"},
+	{"explain <playbook>", "show why a playbook is or is not served"},
+	{"graph", "draw how the global and project libraries combine"},
+	{"stale", "list playbooks overdue for review"},
+	{"trust", "approve the project library after reviewing it"},
+}
+
+// PrintHelp outputs the help text listing all available playbooks.
+// The operating rules section is omitted when rules is empty.
+func PrintHelp(w io.Writer, reg *registry.Snapshot, rules []string) {
+	fmt.Fprintln(w, "Usage: howto [flags] [PLAYBOOK|@BUNDLE...]")
+	fmt.Fprintln(w, "       howto [flags] <command> [args]")
 	fmt.Fprintln(w)
 	fmt.Fprintln(w, "`howto` lets language models pull the exact playbooks their operators prepared.")
 	fmt.Fprintln(w, "Run it to list playbooks, then fetch the one you need with `howto <playbook>`.")
+	fmt.Fprintln(w, "Fetch several at once with `howto <playbook> <playbook> @<bundle>`.")
 	fmt.Fprintln(w)
-	fmt.Fprintln(w, "LLM operating rules:")
-	for _, rule := range instructions.LLMBullets() {
-		fmt.Fprintf(w, "- %s\n", rule)
+	fmt.Fprintln(w, "Commands:")
+	for _, command := range helpCommands {
+		fmt.Fprintf(w, "  %-22s %s\n", command[0], command[1])
 	}
 	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Flags: --format text|json|xml, --max-tokens N, --profile NAME, --strict, --version.")
+	fmt.Fprintln(w, "A playbook named like a command is fetched with `howto -- <playbook>`.")
+	fmt.Fprintln(w)
+	if len(rules) > 0 {
+		fmt.Fprintln(w, "LLM operating rules:")
+		for _, rule := range rules {
+			fmt.Fprintf(w, "- %s\n", rule)
+		}
+		fmt.Fprintln(w)
+	}
 
-	docs := reg.GetAll()
+	docs := reg.Listed()
 	if len(docs) == 0 {
 		fmt.Fprintln(w, "No playbooks available.")
 		return
@@ -31,22 +56,156 @@ func PrintHelp(w io.Writer, reg registry.Registry) {
 	fmt.Fprintln(w, "Playbooks:")
 	for _, doc := range docs {
 		description := oneLineDescription(doc.Description)
-		fmt.Fprintf(w, "  %s: %s\n", doc.Name, description)
+		fmt.Fprintf(w, "  %s: %s (%s)\n", doc.Name, description, FormatTokens(tokens.Estimate(doc.Content)))
+	}
+
+	if bundles := reg.Bundles(); len(bundles) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Bundles (fetch all members at once with `howto @<bundle>`):")
+		for _, bundle := range bundles {
+			fmt.Fprintf(w, "  %s%s: %s\n", registry.BundlePrefix, bundle, strings.Join(reg.BundleMembers(bundle), ", "))
+		}
 	}
 }
 
-// PrintPlaybook outputs the full content of a specific playbook
-func PrintPlaybook(w io.Writer, doc registry.Registry, name string) error {
-	d, ok := doc.Get(name)
-	if !ok {
-		return fmt.Errorf("unknown playbook: %s", name)
+// PrintPlaybook outputs the full content of a specific playbook, or of every
+// member of a bundle when name starts with @
+func PrintPlaybook(w io.Writer, reg *registry.Snapshot, name string) error {
+	return PrintPlaybooks(w, reg, []string{name}, 0)
+}
+
+// PrintPlaybooks outputs several playbooks or bundles in the order given, each
+// under a header and separated by horizontal rules (see FormatPlaybooks).
+// Playbooks requested more than once are printed once. A single playbook name
+// prints just that playbook's content. A positive maxTokens caps the output at
+// that many tokens (see FitDocuments).
+func PrintPlaybooks(w io.Writer, reg *registry.Snapshot, names []string, maxTokens int) error {
+	var docs []parser.Document
+	var err error
+	if len(names) == 1 {
+		docs, err = reg.Resolve(names[0])
+	} else {
+		docs, err = reg.ResolveAll(names)
+	}
+	if err != nil {
+		return err
 	}
+	docs = xref.RewriteDocuments(ApplyDeprecations(reg, docs), xref.StyleCLI)
 
-	// Output just the markdown content (no frontmatter)
-	fmt.Fprintln(w, d.Content)
+	render := func(docs []parser.Document) string {
+		return FormatPlaybooks(docs) + "\n"
+	}
+	if len(names) == 1 && !registry.IsBundleName(names[0]) {
+		// Output just the markdown content (no frontmatter), marked with the version served
+		render = func(docs []parser.Document) string {
+			marker := ""
+			if docs[0].Version != "" {
+				marker = fmt.Sprintf("<!-- howto: %s version %s -->\n", docs[0].Name, docs[0].Version)
+			}
+			return marker + docs[0].Content + "\n"
+		}
+	}
+
+	docs, _, err = fitDocuments(docs, maxTokens, render)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(w, render(docs))
 	return nil
 }
 
+// FormatPlaybooks joins several playbooks into one Markdown document, giving
+// each a header (with the version served, if any) and separating them with horizontal rules
+func FormatPlaybooks(docs []parser.Document) string {
+	parts := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		header := doc.Name
+		if doc.Version != "" {
+			header += " (version " + doc.Version + ")"
+		}
+		parts = append(parts, fmt.Sprintf("# Playbook: %s\n\n%s", header, strings.TrimSpace(doc.Content)))
+	}
+	return strings.Join(parts, "\n\n---\n\n")
+}
+
+// ApplyDeprecations returns copies of docs in which every deprecated playbook
+// starts with a deprecation notice. When the playbook has an available
+// replacement, the replacement's content follows the notice instead of the
+// deprecated content, so callers using an old name still get current instructions.
+func ApplyDeprecations(reg *registry.Snapshot, docs []parser.Document) []parser.Document {
+	out := make([]parser.Document, len(docs))
+	for i, doc := range docs {
+		if doc.Deprecated {
+			replacement, ok := reg.Replacement(doc.Name)
+			content := doc.Content
+			if ok {
+				content = replacement.Content
+			}
+			doc.Content = DeprecationNotice(doc, replacement.Name) + "\n\n" + strings.TrimSpace(content)
+		}
+		out[i] = doc
+	}
+	return out
+}
+
+// DeprecationNotice formats the notice shown when a deprecated playbook is fetched.
+// replacement is the name served instead, or "" when there is none.
+func DeprecationNotice(doc parser.Document, replacement string) string {
+	var b strings.Builder
+	if replacement != "" {
+		fmt.Fprintf(&b, "> **Deprecated:** the `%s` playbook has been replaced by `%s`. Use `%s` from now on; its content follows.", doc.Name, replacement, replacement)
+	} else {
+		fmt.Fprintf(&b, "> **Deprecated:** the `%s` playbook is deprecated and may be removed.", doc.Name)
+	}
+	if doc.DeprecationNote != "" {
+		b.WriteString("\n>\n> " + strings.Join(strings.Split(doc.DeprecationNote, "\n"), "\n> "))
+	}
+	return b.String()
+}
+
+// PrintExplanation outputs every candidate document considered for a playbook name and what happened to it
+func PrintExplanation(w io.Writer, provenance registry.Provenance, name string) error {
+	candidates, err := provenance.Lookup(name)
+	if err != nil {
+		return err
+	}
+
+	served := false
+	for _, candidate := range candidates {
+		served = served || candidate.Served()
+	}
+	if served {
+		fmt.Fprintf(w, "%s is served.\n", name)
+	} else {
+		fmt.Fprintf(w, "%s is not served.\n", name)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Candidates, in the order they were considered:")
+	for i, candidate := range candidates {
+		fmt.Fprintf(w, "  %d. %s\n", i+1, candidate)
+	}
+	return nil
+}
+
+// PrintSearchResults outputs ranked search results with their matching lines
+func PrintSearchResults(w io.Writer, query string, results []search.Result) {
+	if len(results) == 0 {
+		fmt.Fprintf(w, "No playbooks match %q.\n", query)
+		return
+	}
+
+	fmt.Fprintf(w, "Playbooks matching %q:\n", query)
+	for _, result := range results {
+		fmt.Fprintf(w, "  %s: %s\n", result.Name, oneLineDescription(result.Description))
+		for _, snippet := range result.Snippets {
+			fmt.Fprintf(w, "    %d: %s\n", snippet.Line, snippet.Text)
+		}
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Fetch a playbook with `howto <playbook>`.")
+}
+
 // oneLineDescription collapses whitespace so the description prints on one line
 func oneLineDescription(text string) string {
 	fields := strings.Fields(text)
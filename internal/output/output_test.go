@@ -6,8 +6,10 @@ import (
 	"testing"
 
 	"github.com/yourusername/howto/internal/config"
+	"github.com/yourusername/howto/internal/instructions"
 	"github.com/yourusername/howto/internal/parser"
 	"github.com/yourusername/howto/internal/registry"
+	"github.com/yourusername/howto/internal/search"
 )
 
 func TestPrintHelp_WithPlaybooks(t *testing.T) {
@@ -21,13 +23,13 @@ func TestPrintHelp_WithPlaybooks(t *testing.T) {
 	reg := registry.BuildRegistry(globalDocs, projectDocs, &config.ProjectConfig{})
 
 	var buf bytes.Buffer
-	PrintHelp(&buf, reg)
+	PrintHelp(&buf, registry.NewSnapshot(reg), instructions.LLMBullets())
 
 	output := buf.String()
 
 	// Check for expected elements
-	if !strings.Contains(output, "Usage: howto [PLAYBOOK]") {
-		t.Error("expected usage line in output")
+	if !strings.Contains(output, "Usage: howto [flags] [PLAYBOOK|@BUNDLE...]") || !strings.Contains(output, "  explain <playbook>") {
+		t.Error("expected usage line and commands in output")
 	}
 
 	if !strings.Contains(output, "`howto` lets language models pull the exact playbooks their operators prepared.") {
@@ -55,7 +57,7 @@ func TestPrintHelp_Empty(t *testing.T) {
 	reg := registry.BuildRegistry(nil, nil, &config.ProjectConfig{})
 
 	var buf bytes.Buffer
-	PrintHelp(&buf, reg)
+	PrintHelp(&buf, registry.NewSnapshot(reg), instructions.LLMBullets())
 
 	output := buf.String()
 
@@ -74,7 +76,7 @@ func TestPrintHelp_SortedByFilename(t *testing.T) {
 	reg := registry.BuildRegistry(nil, docs, &config.ProjectConfig{})
 
 	var buf bytes.Buffer
-	PrintHelp(&buf, reg)
+	PrintHelp(&buf, registry.NewSnapshot(reg), instructions.LLMBullets())
 
 	output := buf.String()
 
@@ -107,7 +109,7 @@ func TestPrintPlaybook_Success(t *testing.T) {
 	reg := registry.BuildRegistry(nil, docs, &config.ProjectConfig{})
 
 	var buf bytes.Buffer
-	err := PrintPlaybook(&buf, reg, "test-doc")
+	err := PrintPlaybook(&buf, registry.NewSnapshot(reg), "test-doc")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -124,7 +126,7 @@ func TestPrintPlaybook_NotFound(t *testing.T) {
 	reg := registry.BuildRegistry(nil, nil, &config.ProjectConfig{})
 
 	var buf bytes.Buffer
-	err := PrintPlaybook(&buf, reg, "nonexistent")
+	err := PrintPlaybook(&buf, registry.NewSnapshot(reg), "nonexistent")
 	if err == nil {
 		t.Fatal("expected error for nonexistent playbook")
 	}
@@ -134,6 +136,170 @@ func TestPrintPlaybook_NotFound(t *testing.T) {
 	}
 }
 
+func TestPrintPlaybook_NotFoundSuggestsNames(t *testing.T) {
+	reg := registry.Registry{
+		"go-lang": {Name: "go-lang", Aliases: []string{"golang"}},
+		"commits": {Name: "commits"},
+	}
+
+	var buf bytes.Buffer
+	err := PrintPlaybook(&buf, registry.NewSnapshot(reg), "golang")
+	if err == nil {
+		t.Fatal("expected error for unknown playbook")
+	}
+
+	expected := `unknown playbook: golang; did you mean "go-lang"?`
+	if err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+}
+
+func TestPrintExplanation(t *testing.T) {
+	provenance := registry.Provenance{
+		"optional-rule": {{
+			Source:  parser.SourceGlobal,
+			Path:    "/global/optional-rule.md",
+			Outcome: registry.OutcomeNotRequired,
+			Reason:  "required: false and not listed in require",
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := PrintExplanation(&buf, provenance, "optional-rule"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `optional-rule is not served.
+
+Candidates, in the order they were considered:
+  1. global /global/optional-rule.md: not-required (required: false and not listed in require)
+`
+	if buf.String() != expected {
+		t.Errorf("expected output:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	if err := PrintExplanation(&buf, provenance, "optional-rules"); err == nil || !strings.Contains(err.Error(), "unknown playbook") {
+		t.Errorf("expected unknown playbook error, got %v", err)
+	}
+}
+
+func TestPrintPlaybook_Bundle(t *testing.T) {
+	reg := registry.Registry{
+		"go-lang": {Name: "go-lang", Content: "Handle errors."},
+		"commits": {Name: "commits", Content: "Be brief."},
+	}
+	snapshot := registry.NewSnapshot(reg).WithBundles(config.Bundles{"backend": {"go-lang", "commits"}})
+
+	var buf bytes.Buffer
+	if err := PrintPlaybook(&buf, snapshot, "@backend"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "# Playbook: go-lang\n\nHandle errors.\n\n---\n\n# Playbook: commits\n\nBe brief.\n"
+	if buf.String() != expected {
+		t.Errorf("expected output:\n%s\ngot:\n%s", expected, buf.String())
+	}
+
+	buf.Reset()
+	PrintHelp(&buf, snapshot, nil)
+	if !strings.Contains(buf.String(), "  @backend: go-lang, commits") {
+		t.Errorf("expected bundle in help output, got:\n%s", buf.String())
+	}
+}
+
+func TestPrintPlaybook_RewritesLinks(t *testing.T) {
+	reg := registry.Registry{
+		"go-lang": {Name: "go-lang", Content: "Commit with [[commits]]."},
+		"commits": {Name: "commits", Content: "Be brief."},
+	}
+
+	var buf bytes.Buffer
+	if err := PrintPlaybook(&buf, registry.NewSnapshot(reg), "go-lang"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "Commit with commits (`howto commits`).\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestPrintPlaybook_Deprecated(t *testing.T) {
+	reg := registry.Registry{
+		"go-lang": {Name: "go-lang", Description: "Go", Content: "New rules."},
+		"golang":  {Name: "golang", Description: "Old Go", Content: "Old rules.", Deprecated: true, ReplacedBy: "go-lang", DeprecationNote: "Renamed."},
+	}
+	snapshot := registry.NewSnapshot(reg)
+
+	var buf bytes.Buffer
+	if err := PrintPlaybook(&buf, snapshot, "golang"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	output := buf.String()
+	if !strings.HasPrefix(output, "> **Deprecated:** the `golang` playbook has been replaced by `go-lang`.") {
+		t.Errorf("expected deprecation notice first, got %q", output)
+	}
+	if !strings.Contains(output, "> Renamed.") || !strings.HasSuffix(output, "New rules.\n") || strings.Contains(output, "Old rules.") {
+		t.Errorf("expected note and replacement content, got %q", output)
+	}
+
+	buf.Reset()
+	PrintHelp(&buf, snapshot, nil)
+	if strings.Contains(buf.String(), "golang") {
+		t.Errorf("expected deprecated playbook to be hidden from the listing, got %q", buf.String())
+	}
+}
+
+func TestPrintPlaybook_ShowsVersion(t *testing.T) {
+	reg := registry.Registry{
+		"go-lang": {Name: "go-lang", Version: "2.1.0", Content: "Handle errors."},
+		"commits": {Name: "commits", Content: "Be brief."},
+	}
+	snapshot := registry.NewSnapshot(reg).WithBundles(config.Bundles{"backend": {"go-lang", "commits"}})
+
+	var buf bytes.Buffer
+	if err := PrintPlaybook(&buf, snapshot, "go-lang"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "<!-- howto: go-lang version 2.1.0 -->\nHandle errors.\n"; buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	if err := PrintPlaybook(&buf, snapshot, "@backend"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "# Playbook: go-lang (version 2.1.0)") || !strings.Contains(buf.String(), "# Playbook: commits\n") {
+		t.Errorf("expected versioned bundle headers, got %q", buf.String())
+	}
+}
+
+func TestPrintPlaybooks(t *testing.T) {
+	reg := registry.Registry{
+		"go-lang": {Name: "go-lang", Content: "Handle errors."},
+		"commits": {Name: "commits", Content: "Be brief."},
+	}
+	snapshot := registry.NewSnapshot(reg)
+
+	var buf bytes.Buffer
+	if err := PrintPlaybooks(&buf, snapshot, []string{"commits", "go-lang", "commits"}, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "# Playbook: commits\n\nBe brief.\n\n---\n\n# Playbook: go-lang\n\nHandle errors.\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+
+	buf.Reset()
+	err := PrintPlaybooks(&buf, snapshot, []string{"comits", "go-lang", "go-lan"}, 0)
+	if err == nil || !strings.Contains(err.Error(), "comits") || !strings.Contains(err.Error(), "go-lan ") {
+		t.Errorf("expected every unknown name in the error, got %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no output when a name is unknown, got %q", buf.String())
+	}
+}
+
 func TestPrintPlaybook_OnlyContent(t *testing.T) {
 	// Ensure frontmatter is not included in output
 	docs := []parser.Document{
@@ -149,7 +315,7 @@ func TestPrintPlaybook_OnlyContent(t *testing.T) {
 	reg := registry.BuildRegistry(nil, docs, &config.ProjectConfig{})
 
 	var buf bytes.Buffer
-	err := PrintPlaybook(&buf, reg, "doc")
+	err := PrintPlaybook(&buf, registry.NewSnapshot(reg), "doc")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -211,7 +377,7 @@ func TestPrintHelp_PlaybookListing(t *testing.T) {
 	reg := registry.BuildRegistry(nil, docs, &config.ProjectConfig{})
 
 	var buf bytes.Buffer
-	PrintHelp(&buf, reg)
+	PrintHelp(&buf, registry.NewSnapshot(reg), instructions.LLMBullets())
 
 	output := buf.String()
 
@@ -220,3 +386,23 @@ func TestPrintHelp_PlaybookListing(t *testing.T) {
 		t.Errorf("expected playbook listing %q in output, got:\n%s", expectedLine, output)
 	}
 }
+
+func TestPrintSearchResults(t *testing.T) {
+	results := []search.Result{
+		{Name: "db-migrations", Description: "Database migrations", Snippets: []search.Snippet{{Line: 3, Text: "Run migrations in a transaction."}}},
+	}
+
+	var buf bytes.Buffer
+	PrintSearchResults(&buf, "migrations", results)
+	output := buf.String()
+
+	if !strings.Contains(output, "  db-migrations: Database migrations\n    3: Run migrations in a transaction.\n") {
+		t.Errorf("unexpected search output:\n%s", output)
+	}
+
+	buf.Reset()
+	PrintSearchResults(&buf, "nothing", nil)
+	if !strings.Contains(buf.String(), `No playbooks match "nothing".`) {
+		t.Errorf("expected no-match message, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,172 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/howto/internal/registry"
+	"github.com/yourusername/howto/internal/xref"
+)
+
+// The XML renderings wrap playbooks in tags so harnesses can paste them straight
+// into prompts. They use the same fields as the JSON objects, as attributes, with
+// the Markdown content as element text. Content is escaped (&, < and >) but keeps
+// its line breaks, so a playbook that mentions </playbook> cannot close the element.
+
+// FormatXMLCatalogue renders the listing as a <catalogue> element with one empty
+// <playbook> element per listed playbook and one <bundle> element per bundle.
+func FormatXMLCatalogue(catalogue Catalogue) string {
+	var b strings.Builder
+	b.WriteString("<catalogue" + xmlAttrs("revision", catalogue.Revision, "trust", catalogue.Trust) + ">\n")
+	if len(catalogue.Rules) > 0 {
+		b.WriteString("<rules>\n")
+		for _, rule := range catalogue.Rules {
+			b.WriteString("<rule>" + xmlText(rule) + "</rule>\n")
+		}
+		b.WriteString("</rules>\n")
+	}
+	for _, entry := range catalogue.Playbooks {
+		b.WriteString("<playbook" + xmlAttrs(entryAttrs(entry)...) + " />\n")
+	}
+	for _, bundle := range catalogue.Bundles {
+		b.WriteString("<bundle" + xmlAttrs("name", registry.BundlePrefix+bundle.Name, "members", strings.Join(bundle.Members, ", ")) + " />\n")
+	}
+	b.WriteString("</catalogue>\n")
+	return b.String()
+}
+
+// FormatXMLPlaybook renders a fetched playbook as a <playbook> element.
+func FormatXMLPlaybook(playbook Playbook) string {
+	attrs := []string{"name", playbook.Name, "source", playbook.Source, "revision", playbook.Revision, "hash", playbook.Hash}
+	if playbook.Version != "" {
+		attrs = append(attrs, "version", playbook.Version)
+	}
+	if playbook.ReviewBy != "" {
+		attrs = append(attrs, "review_by", playbook.ReviewBy)
+	}
+	if playbook.Overdue {
+		attrs = append(attrs, "overdue", "true")
+	}
+	if playbook.Deprecated {
+		attrs = append(attrs, "deprecated", "true")
+	}
+	if playbook.Redirect != nil {
+		attrs = append(attrs, "replaced_by", playbook.Redirect.To)
+	}
+	if len(playbook.OmittedSections) > 0 {
+		attrs = append(attrs, "omitted_sections", strings.Join(playbook.OmittedSections, ", "))
+	}
+	return "<playbook" + xmlAttrs(attrs...) + ">\n" + xmlText(strings.TrimSpace(playbook.Content)) + "\n</playbook>\n"
+}
+
+// FormatXMLPlaybooks renders several fetched playbooks as <playbook> elements
+// inside a <playbooks> element, which names the bundle if there is one.
+func FormatXMLPlaybooks(bundle Bundle) string {
+	var b strings.Builder
+	b.WriteString("<playbooks" + xmlAttrs("bundle", bundle.Name, "revision", bundle.Revision) + ">\n")
+	for _, playbook := range bundle.Playbooks {
+		b.WriteString(FormatXMLPlaybook(playbook))
+	}
+	b.WriteString("</playbooks>\n")
+	return b.String()
+}
+
+// FormatXMLError renders a failure as an <error> element, with an <unknown>
+// element for every name that did not resolve.
+func FormatXMLError(failure ErrorJSON) string {
+	var b strings.Builder
+	b.WriteString("<error" + xmlAttrs("code", failure.Error.Code) + ">\n")
+	b.WriteString("<message>" + xmlText(failure.Error.Message) + "</message>\n")
+	for _, unknown := range failure.Error.Unknown {
+		b.WriteString("<unknown" + xmlAttrs("name", unknown.Name, "suggestions", strings.Join(unknown.Suggestions, ", ")) + " />\n")
+	}
+	b.WriteString("</error>\n")
+	return b.String()
+}
+
+// PrintXMLPlaybooks outputs the playbooks names refer to as XML: a <playbook>
+// element for a single playbook name, otherwise a <playbooks> element.
+// A positive maxTokens trims the content (see FitContent and FitPlaybooks).
+func PrintXMLPlaybooks(w io.Writer, reg *registry.Snapshot, names []string, trust string, maxTokens int) error {
+	bundle, single, err := fetch(reg, names, trust, xref.StyleCLI, maxTokens)
+	if err != nil {
+		return err
+	}
+	if single {
+		fmt.Fprint(w, FormatXMLPlaybook(bundle.Playbooks[0]))
+		return nil
+	}
+	fmt.Fprint(w, FormatXMLPlaybooks(bundle))
+	return nil
+}
+
+func entryAttrs(entry CatalogueEntry) []string {
+	attrs := []string{"name", entry.Name, "description", oneLineDescription(entry.Description), "source", entry.Source, "priority", entry.Priority}
+	if len(entry.Tags) > 0 {
+		attrs = append(attrs, "tags", strings.Join(entry.Tags, ", "))
+	}
+	if entry.Version != "" {
+		attrs = append(attrs, "version", entry.Version)
+	}
+	return append(attrs, "hash", entry.Hash, "tokens", strconv.Itoa(entry.Tokens))
+}
+
+// xmlAttrs renders name/value pairs as attributes, skipping empty values
+func xmlAttrs(pairs ...string) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] == "" {
+			continue
+		}
+		fmt.Fprintf(&b, ` %s="%s"`, pairs[i], xmlAttr(pairs[i+1]))
+	}
+	return b.String()
+}
+
+// xmlText escapes element text. Line breaks and tabs are kept so Markdown stays readable.
+func xmlText(text string) string {
+	return xmlEscape(text, false)
+}
+
+// xmlAttr escapes an attribute value, including quotes and whitespace that
+// would otherwise be normalised away
+func xmlAttr(text string) string {
+	return xmlEscape(text, true)
+}
+
+func xmlEscape(text string, attr bool) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '&':
+			b.WriteString("&amp;")
+		case r == '<':
+			b.WriteString("&lt;")
+		case r == '>':
+			b.WriteString("&gt;")
+		case attr && r == '"':
+			b.WriteString("&quot;")
+		case attr && r == '\n':
+			b.WriteString("&#xA;")
+		case attr && r == '\t':
+			b.WriteString("&#x9;")
+		case r == '\r':
+			b.WriteString("&#xD;")
+		case !isXMLChar(r):
+			b.WriteRune('\uFFFD') // Invalid UTF-8 already decodes to U+FFFD
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// isXMLChar reports whether r may appear in an XML 1.0 document
+func isXMLChar(r rune) bool {
+	return r == 0x09 || r == 0x0A || r == 0x0D ||
+		r >= 0x20 && r <= 0xD7FF ||
+		r >= 0xE000 && r <= 0xFFFD ||
+		r >= 0x10000 && r <= 0x10FFFF
+}
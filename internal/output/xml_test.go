@@ -0,0 +1,153 @@
+package output
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/howto/internal/parser"
+	"github.com/yourusername/howto/internal/registry"
+	"github.com/yourusername/howto/internal/xref"
+)
+
+// xmlPlaybook decodes a <playbook> element for assertions
+type xmlPlaybook struct {
+	Name       string `xml:"name,attr"`
+	Source     string `xml:"source,attr"`
+	Revision   string `xml:"revision,attr"`
+	Hash       string `xml:"hash,attr"`
+	Version    string `xml:"version,attr"`
+	ReplacedBy string `xml:"replaced_by,attr"`
+	Content    string `xml:",chardata"`
+}
+
+func TestFormatXMLPlaybookEscapesContent(t *testing.T) {
+	reg := registry.Registry{
+		"tricky": {Name: "tricky", Description: "Tricky", Content: "Never write </playbook> & <b>bold</b>.\r\nDone.", Source: parser.SourceGlobal},
+	}
+	snapshot := registry.NewSnapshot(reg)
+	doc, _ := snapshot.Lookup("tricky")
+
+	rendered := FormatXMLPlaybook(NewPlaybook(snapshot, doc, xref.StyleCLI))
+	if strings.Count(rendered, "</playbook>") != 1 {
+		t.Fatalf("expected content not to close the element, got:\n%s", rendered)
+	}
+
+	var playbook xmlPlaybook
+	if err := xml.Unmarshal([]byte(rendered), &playbook); err != nil {
+		t.Fatalf("expected well-formed XML, got %v:\n%s", err, rendered)
+	}
+	if playbook.Name != "tricky" || playbook.Source != "global" || playbook.Revision != snapshot.Revision() || playbook.Hash != snapshot.Hash("tricky") {
+		t.Errorf("unexpected attributes: %+v", playbook)
+	}
+	if want := "\nNever write </playbook> & <b>bold</b>.\r\nDone.\n"; playbook.Content != want {
+		t.Errorf("expected content to round-trip, got %q", playbook.Content)
+	}
+}
+
+func TestXMLAttrEscaping(t *testing.T) {
+	rendered := "<x" + xmlAttrs("a", "say \"hi\" & <go>\n\tnow\x00", "empty", "") + " />"
+
+	var decoded struct {
+		A     string `xml:"a,attr"`
+		Empty string `xml:"empty,attr"`
+	}
+	if err := xml.Unmarshal([]byte(rendered), &decoded); err != nil {
+		t.Fatalf("expected well-formed XML, got %v: %s", err, rendered)
+	}
+	if decoded.A != "say \"hi\" & <go>\n\tnow\uFFFD" {
+		t.Errorf("expected attribute to round-trip with the invalid character replaced, got %q", decoded.A)
+	}
+	if strings.Contains(rendered, "empty=") {
+		t.Errorf("expected empty attributes to be skipped, got %s", rendered)
+	}
+}
+
+func TestFormatXMLCatalogue(t *testing.T) {
+	snapshot := jsonTestSnapshot()
+	rendered := FormatXMLCatalogue(NewCatalogue(snapshot, []string{"Fetch <one> & more"}, "trusted", nil))
+
+	var catalogue struct {
+		Revision  string   `xml:"revision,attr"`
+		Trust     string   `xml:"trust,attr"`
+		Rules     []string `xml:"rules>rule"`
+		Playbooks []struct {
+			Name        string `xml:"name,attr"`
+			Description string `xml:"description,attr"`
+			Version     string `xml:"version,attr"`
+		} `xml:"playbook"`
+		Bundles []struct {
+			Name    string `xml:"name,attr"`
+			Members string `xml:"members,attr"`
+		} `xml:"bundle"`
+	}
+	if err := xml.Unmarshal([]byte(rendered), &catalogue); err != nil {
+		t.Fatalf("expected well-formed XML, got %v:\n%s", err, rendered)
+	}
+	if catalogue.Revision != snapshot.Revision() || catalogue.Trust != "trusted" || len(catalogue.Rules) != 1 || catalogue.Rules[0] != "Fetch <one> & more" {
+		t.Errorf("unexpected catalogue header: %+v", catalogue)
+	}
+	if len(catalogue.Playbooks) != 2 || catalogue.Playbooks[1].Name != "go-lang" || catalogue.Playbooks[1].Description != "Go <rules>" || catalogue.Playbooks[1].Version != "2.0.0" {
+		t.Errorf("unexpected playbooks: %+v", catalogue.Playbooks)
+	}
+	if len(catalogue.Bundles) != 1 || catalogue.Bundles[0].Name != "@backend" || catalogue.Bundles[0].Members != "go-lang, commits" {
+		t.Errorf("unexpected bundles: %+v", catalogue.Bundles)
+	}
+}
+
+func TestPrintXMLPlaybooks(t *testing.T) {
+	snapshot := jsonTestSnapshot()
+
+	var buf bytes.Buffer
+	if err := PrintXMLPlaybooks(&buf, snapshot, []string{"golang"}, "", 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var playbook xmlPlaybook
+	if err := xml.Unmarshal(buf.Bytes(), &playbook); err != nil {
+		t.Fatalf("expected well-formed XML, got %v:\n%s", err, buf.String())
+	}
+	if playbook.Name != "golang" || playbook.ReplacedBy != "go-lang" || !strings.Contains(playbook.Content, "Use commits (`howto commits`) & test.") {
+		t.Errorf("expected the redirected playbook, got %+v", playbook)
+	}
+
+	buf.Reset()
+	if err := PrintXMLPlaybooks(&buf, snapshot, []string{"@backend"}, "trusted", 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var bundle struct {
+		Bundle    string        `xml:"bundle,attr"`
+		Playbooks []xmlPlaybook `xml:"playbook"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &bundle); err != nil {
+		t.Fatalf("expected well-formed XML, got %v:\n%s", err, buf.String())
+	}
+	if bundle.Bundle != "@backend" || len(bundle.Playbooks) != 2 || bundle.Playbooks[1].Content != "\nBe brief.\n" {
+		t.Errorf("unexpected bundle: %+v", bundle)
+	}
+}
+
+func TestFormatXMLError(t *testing.T) {
+	_, err := jsonTestSnapshot().ResolveAll([]string{"go-lan", "comits"})
+	rendered := FormatXMLError(NewError(err))
+
+	var failure struct {
+		Code    string `xml:"code,attr"`
+		Message string `xml:"message"`
+		Unknown []struct {
+			Name        string `xml:"name,attr"`
+			Suggestions string `xml:"suggestions,attr"`
+		} `xml:"unknown"`
+	}
+	if err := xml.Unmarshal([]byte(rendered), &failure); err != nil {
+		t.Fatalf("expected well-formed XML, got %v:\n%s", err, rendered)
+	}
+	if failure.Code != "unknown_playbook" || !strings.Contains(failure.Message, `"go-lang"`) || len(failure.Unknown) != 2 || !strings.HasPrefix(failure.Unknown[0].Suggestions, "go-lang") {
+		t.Errorf("unexpected error: %+v", failure)
+	}
+
+	if rendered := FormatXMLError(NewError(fmt.Errorf("a < b"))); !strings.Contains(rendered, "<message>a &lt; b</message>") {
+		t.Errorf("expected the message to be escaped, got %s", rendered)
+	}
+}
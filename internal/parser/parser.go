@@ -6,7 +6,10 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
+	"unicode"
 
+	"github.com/yourusername/howto/internal/semver"
 	"gopkg.in/yaml.v3"
 )
 
@@ -29,21 +32,159 @@ func (s Source) String() string {
 	}
 }
 
+// MergeStrategy controls how a project document combines with the global document of the same name
+type MergeStrategy string
+
+const (
+	MergeReplace MergeStrategy = "replace" // Project content replaces the global content (default)
+	MergeAppend  MergeStrategy = "append"  // Project content is added after the global content
+	MergePrepend MergeStrategy = "prepend" // Project content is added before the global content
+)
+
+// ParseMergeStrategy validates a merge value from frontmatter. An empty value means replace.
+func ParseMergeStrategy(value string) (MergeStrategy, error) {
+	switch MergeStrategy(strings.ToLower(strings.TrimSpace(value))) {
+	case "", MergeReplace:
+		return MergeReplace, nil
+	case MergeAppend:
+		return MergeAppend, nil
+	case MergePrepend:
+		return MergePrepend, nil
+	default:
+		return "", fmt.Errorf("invalid merge strategy %q (expected append, prepend or replace)", value)
+	}
+}
+
+// Priority ranks how important a document is. Listings put higher priorities first,
+// and anything trimming the catalogue to a budget drops lower priorities first.
+type Priority string
+
+const (
+	PriorityCritical Priority = "critical"
+	PriorityHigh     Priority = "high"
+	PriorityNormal   Priority = "normal" // Default
+	PriorityLow      Priority = "low"
+)
+
+// ParsePriority validates a priority value from frontmatter. An empty value means normal.
+func ParsePriority(value string) (Priority, error) {
+	switch Priority(strings.ToLower(strings.TrimSpace(value))) {
+	case "", PriorityNormal:
+		return PriorityNormal, nil
+	case PriorityCritical:
+		return PriorityCritical, nil
+	case PriorityHigh:
+		return PriorityHigh, nil
+	case PriorityLow:
+		return PriorityLow, nil
+	default:
+		return "", fmt.Errorf("invalid priority %q (expected critical, high, normal or low)", value)
+	}
+}
+
+// Rank orders priorities numerically; higher is more important. An empty priority ranks as normal.
+func (p Priority) Rank() int {
+	switch p {
+	case PriorityCritical:
+		return 3
+	case PriorityHigh:
+		return 2
+	case PriorityLow:
+		return 0
+	default:
+		return 1
+	}
+}
+
 // Document represents a parsed markdown file with YAML frontmatter
 type Document struct {
-	Name        string // From frontmatter or filename
-	Description string // Required field
-	Required    bool   // Default: true (global only)
-	Content     string // Markdown body (no frontmatter)
-	Source      Source // Global or ProjectScoped
-	FilePath    string // Original file path for debugging
+	Name            string              // From frontmatter or filename
+	Description     string              // Required field
+	Required        bool                // Default: true (global only)
+	Merge           MergeStrategy       // How a project doc combines with the global doc; empty means replace
+	Final           bool                // Global docs only: projects may not override this doc
+	Agents          []string            // Agents this doc is meant for; empty means every agent
+	Tags            []string            // Free-form keywords used for search
+	Aliases         []string            // Other names agents may use; suggested when they ask for them
+	Priority        Priority            // Importance; listings and trimming favour higher priorities
+	Order           int                 // Position among docs of the same priority; 0 means unordered (listed after ordered docs)
+	Bundles         []string            // Bundles this doc joins, after the members listed in config
+	BundleDefs      map[string][]string // Bundles this doc defines, as in config
+	Deprecated      bool                // Hidden from listings but still fetchable
+	ReplacedBy      string              // Playbook served in place of a deprecated one
+	DeprecationNote string              // Shown with the deprecation notice
+	Version         string              // Normalised semver (e.g. 2.1.0) from front matter or a name@2.md filename; empty if unversioned
+	Owners          []string            // People or teams responsible for keeping the doc current
+	ReviewBy        time.Time           // Date by which the doc should be reviewed; zero if unset
+	Content         string              // Markdown body (no frontmatter)
+	Source          Source              // Global or ProjectScoped
+	FilePath        string              // Original file path for debugging
+	BodyLine        int                 // 1-based line in FilePath where Content starts; 0 if unknown
 }
 
 // frontmatter represents the YAML metadata structure
 type frontmatter struct {
-	Name        string `yaml:"name"`
-	Description string `yaml:"description"`
-	Required    *bool  `yaml:"required"` // Pointer to distinguish unset vs false
+	Name            string   `yaml:"name"`
+	Description     string   `yaml:"description"`
+	Required        *bool    `yaml:"required"` // Pointer to distinguish unset vs false
+	Merge           string   `yaml:"merge"`
+	Final           bool     `yaml:"final"`
+	Agents          []string `yaml:"agents"`
+	Tags            []string `yaml:"tags"`
+	Aliases         []string `yaml:"aliases"`
+	Priority        string   `yaml:"priority"`
+	Order           int      `yaml:"order"`
+	Bundles         bundles  `yaml:"bundles"`
+	Deprecated      bool     `yaml:"deprecated"`
+	ReplacedBy      string   `yaml:"replaced_by"`
+	DeprecationNote string   `yaml:"deprecation_note"`
+	Version         string   `yaml:"version"`
+	Owners          owners   `yaml:"owners"`
+	ReviewBy        string   `yaml:"review_by"`
+}
+
+// ReviewByLayout is the date format of the review_by field
+const ReviewByLayout = "2006-01-02"
+
+// owners accepts either a single owner or a list of owners
+type owners []string
+
+func (o *owners) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		if value.Tag != "!!null" {
+			*o = []string{value.Value}
+		}
+		return nil
+	}
+	var list []string
+	if err := value.Decode(&list); err != nil {
+		return fmt.Errorf("owners must be a name or a list of names")
+	}
+	*o = list
+	return nil
+}
+
+// bundles accepts either a list of bundles to join or a map defining bundles
+type bundles struct {
+	join   []string
+	define map[string][]string
+}
+
+func (b *bundles) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.SequenceNode:
+		return value.Decode(&b.join)
+	case yaml.MappingNode:
+		return value.Decode(&b.define)
+	case yaml.ScalarNode:
+		if value.Tag == "!!null" {
+			return nil
+		}
+		b.join = []string{value.Value}
+		return nil
+	default:
+		return fmt.Errorf("bundles must be a list of bundle names or a map of bundle definitions")
+	}
 }
 
 // ParseFile reads and parses a markdown file with YAML frontmatter
@@ -75,20 +216,62 @@ func ParseContent(content []byte, filename string, source Source, filepath strin
 		return nil, fmt.Errorf("missing required field: description")
 	}
 
+	merge, err := ParseMergeStrategy(meta.Merge)
+	if err != nil {
+		return nil, err
+	}
+
+	priority, err := ParsePriority(meta.Priority)
+	if err != nil {
+		return nil, err
+	}
+	if meta.Order < 0 {
+		return nil, fmt.Errorf("invalid order %d (expected a positive integer)", meta.Order)
+	}
+	var reviewBy time.Time
+	if meta.ReviewBy != "" {
+		reviewBy, err = time.Parse(ReviewByLayout, strings.TrimSpace(meta.ReviewBy))
+		if err != nil {
+			return nil, fmt.Errorf("invalid review_by %q (expected a date like 2025-06-30)", meta.ReviewBy)
+		}
+	}
+
+	base, fileVersion := SplitVersion(strings.TrimSuffix(filename, ".md"))
+	version, err := parseVersion(meta.Version, fileVersion, filename)
+	if err != nil {
+		return nil, err
+	}
+
 	// Build document
 	doc := &Document{
-		Name:        meta.Name,
-		Description: meta.Description,
-		Required:    true, // Default
-		Content:     string(body),
-		Source:      source,
-		FilePath:    filepath,
+		Name:            meta.Name,
+		Description:     meta.Description,
+		Required:        true, // Default
+		Merge:           merge,
+		Final:           meta.Final,
+		Agents:          meta.Agents,
+		Tags:            meta.Tags,
+		Aliases:         meta.Aliases,
+		Priority:        priority,
+		Order:           meta.Order,
+		Bundles:         meta.Bundles.join,
+		BundleDefs:      meta.Bundles.define,
+		Deprecated:      meta.Deprecated || meta.ReplacedBy != "",
+		ReplacedBy:      strings.TrimSpace(meta.ReplacedBy),
+		DeprecationNote: strings.TrimSpace(meta.DeprecationNote),
+		Version:         version,
+		Owners:          meta.Owners,
+		ReviewBy:        reviewBy,
+		Content:         string(body),
+		Source:          source,
+		FilePath:        filepath,
+		BodyLine:        bodyLine(content, body),
 	}
 
 	// Apply defaults
 	if doc.Name == "" {
-		// Default to filename without .md extension
-		doc.Name = strings.TrimSuffix(filename, ".md")
+		// Default to filename without .md extension or version suffix
+		doc.Name = base
 	}
 
 	// Handle required field
@@ -99,6 +282,59 @@ func ParseContent(content []byte, filename string, source Source, filepath strin
 	return doc, nil
 }
 
+// SplitVersion separates a version suffix from a name such as go-lang@2. The
+// suffix is only split off when it parses as a version, so other names pass through unchanged.
+func SplitVersion(name string) (base, version string) {
+	at := strings.LastIndex(name, "@")
+	if at <= 0 {
+		return name, ""
+	}
+	parsed, err := semver.Parse(name[at+1:])
+	if err != nil {
+		return name, ""
+	}
+	return name[:at], parsed.String()
+}
+
+// parseVersion normalises the version field and checks it against the filename's version suffix
+func parseVersion(field, fileVersion, filename string) (string, error) {
+	if strings.TrimSpace(field) == "" {
+		return fileVersion, nil
+	}
+	parsed, err := semver.Parse(field)
+	if err != nil {
+		return "", err
+	}
+	if fileVersion != "" && parsed.String() != fileVersion {
+		return "", fmt.Errorf("version %s does not match the version in the filename %s", parsed, filename)
+	}
+	return parsed.String(), nil
+}
+
+// ForAgent reports whether the doc applies to the named agent.
+// Docs without an agents list apply to every agent; agent names compare case-insensitively.
+func (d Document) ForAgent(agent string) bool {
+	if len(d.Agents) == 0 {
+		return true
+	}
+
+	agent = NormalizeAgent(agent)
+	if agent == "" {
+		return false
+	}
+	for _, candidate := range d.Agents {
+		if NormalizeAgent(candidate) == agent {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeAgent canonicalises an agent name for comparison.
+func NormalizeAgent(agent string) string {
+	return strings.ToLower(strings.TrimSpace(agent))
+}
+
 // extractFrontmatter separates YAML frontmatter from markdown content
 // Expected format:
 // ---
@@ -156,3 +392,14 @@ func extractFrontmatter(content []byte) (frontmatter []byte, body []byte, err er
 
 	return frontmatter, body, nil
 }
+
+// bodyLine returns the 1-based line of content on which the trimmed body starts
+func bodyLine(content, body []byte) int {
+	if len(body) == 0 {
+		return 0
+	}
+	// extractFrontmatter trims the body out of the tail of content; find where it begins
+	rest := bytes.TrimRightFunc(content, unicode.IsSpace)
+	start := len(rest) - len(body)
+	return bytes.Count(content[:start], []byte("\n")) + 1
+}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -232,3 +233,148 @@ description: Test whitespace handling
 		t.Errorf("expected content:\n'%s'\ngot:\n'%s'", expectedContent, doc.Content)
 	}
 }
+
+func TestParseContent_MergeStrategy(t *testing.T) {
+	content := []byte(`---
+description: Extra Go rules
+merge: append
+---
+
+- Run go vet`)
+
+	doc, err := ParseContent(content, "go-lang.md", SourceProjectScoped, "/test/go-lang.md")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if doc.Merge != MergeAppend {
+		t.Errorf("expected merge append, got %q", doc.Merge)
+	}
+
+	defaulted, err := ParseContent([]byte("---\ndescription: Go\n---\nBody"), "go.md", SourceGlobal, "/test/go.md")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if defaulted.Merge != MergeReplace {
+		t.Errorf("expected default merge replace, got %q", defaulted.Merge)
+	}
+
+	if _, err := ParseContent([]byte("---\ndescription: Go\nmerge: sideways\n---\nBody"), "go.md", SourceGlobal, "/test/go.md"); err == nil {
+		t.Error("expected error for invalid merge strategy")
+	}
+}
+
+func TestParseContent_PriorityAndOrder(t *testing.T) {
+	content := []byte(`---
+description: Security checklist
+priority: Critical
+order: 2
+---
+Body`)
+
+	doc, err := ParseContent(content, "security.md", SourceGlobal, "/test/security.md")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if doc.Priority != PriorityCritical || doc.Order != 2 {
+		t.Errorf("expected critical priority and order 2, got %q and %d", doc.Priority, doc.Order)
+	}
+
+	defaulted, err := ParseContent([]byte("---\ndescription: Go\n---\nBody"), "go.md", SourceGlobal, "/test/go.md")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if defaulted.Priority != PriorityNormal || defaulted.Order != 0 {
+		t.Errorf("expected normal priority and no order, got %q and %d", defaulted.Priority, defaulted.Order)
+	}
+
+	if _, err := ParseContent([]byte("---\ndescription: Go\npriority: urgent\n---\nBody"), "go.md", SourceGlobal, "/test/go.md"); err == nil {
+		t.Error("expected error for invalid priority")
+	}
+	if _, err := ParseContent([]byte("---\ndescription: Go\norder: -1\n---\nBody"), "go.md", SourceGlobal, "/test/go.md"); err == nil {
+		t.Error("expected error for negative order")
+	}
+}
+
+func TestParseContent_Bundles(t *testing.T) {
+	joined, err := ParseContent([]byte("---\ndescription: Go\nbundles: [backend, review]\n---\nBody"), "go.md", SourceGlobal, "/test/go.md")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(joined.Bundles) != 2 || joined.Bundles[0] != "backend" || joined.Bundles[1] != "review" {
+		t.Errorf("expected to join backend and review, got %v", joined.Bundles)
+	}
+
+	defining, err := ParseContent([]byte("---\ndescription: Index\nbundles:\n  backend: [go-lang, commits]\n---\nBody"), "index.md", SourceGlobal, "/test/index.md")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if members := defining.BundleDefs["backend"]; len(members) != 2 || members[0] != "go-lang" {
+		t.Errorf("expected backend bundle definition, got %v", defining.BundleDefs)
+	}
+}
+
+func TestParseContent_BodyLine(t *testing.T) {
+	content := []byte("---\ndescription: Go\ntags: [go]\n---\n\nFirst line\nSecond line\n")
+
+	doc, err := ParseContent(content, "go.md", SourceGlobal, "/test/go.md")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if doc.BodyLine != 6 {
+		t.Errorf("expected body to start on line 6, got %d", doc.BodyLine)
+	}
+}
+
+func TestParseContent_Deprecation(t *testing.T) {
+	content := []byte("---\ndescription: Old Go\nreplaced_by: go-lang\ndeprecation_note: Merged into go-lang.\n---\nBody")
+
+	doc, err := ParseContent(content, "golang.md", SourceGlobal, "/test/golang.md")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !doc.Deprecated || doc.ReplacedBy != "go-lang" || doc.DeprecationNote != "Merged into go-lang." {
+		t.Errorf("expected replaced_by to imply deprecation, got %+v", doc)
+	}
+}
+
+func TestParseContent_OwnersAndReviewBy(t *testing.T) {
+	doc, err := ParseContent([]byte("---\ndescription: Deploy\nowners: \"@ops\"\nreview_by: 2025-06-30\n---\nBody"), "deploy.md", SourceGlobal, "/test/deploy.md")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(doc.Owners) != 1 || doc.Owners[0] != "@ops" {
+		t.Errorf("expected a single owner, got %v", doc.Owners)
+	}
+	if doc.ReviewBy.Format(ReviewByLayout) != "2025-06-30" {
+		t.Errorf("expected review_by 2025-06-30, got %v", doc.ReviewBy)
+	}
+
+	if _, err := ParseContent([]byte("---\ndescription: Deploy\nreview_by: next spring\n---\nBody"), "deploy.md", SourceGlobal, "/test/deploy.md"); err == nil || !strings.Contains(err.Error(), "invalid review_by") {
+		t.Errorf("expected invalid review_by error, got %v", err)
+	}
+}
+
+func TestParseContent_Version(t *testing.T) {
+	fromFilename, err := ParseContent([]byte("---\ndescription: Go\n---\nBody"), "go-lang@2.md", SourceGlobal, "/test/go-lang@2.md")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fromFilename.Name != "go-lang" || fromFilename.Version != "2.0.0" {
+		t.Errorf("expected go-lang version 2.0.0 from the filename, got %q version %q", fromFilename.Name, fromFilename.Version)
+	}
+
+	fromField, err := ParseContent([]byte("---\ndescription: Go\nversion: 2.1\n---\nBody"), "go-lang.md", SourceGlobal, "/test/go-lang.md")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fromField.Name != "go-lang" || fromField.Version != "2.1.0" {
+		t.Errorf("expected go-lang version 2.1.0 from the field, got %q version %q", fromField.Name, fromField.Version)
+	}
+
+	if _, err := ParseContent([]byte("---\ndescription: Go\nversion: 3\n---\nBody"), "go-lang@2.md", SourceGlobal, "/test/go-lang@2.md"); err == nil {
+		t.Error("expected an error when the field and filename versions differ")
+	}
+	if _, err := ParseContent([]byte("---\ndescription: Go\nversion: latest\n---\nBody"), "go-lang.md", SourceGlobal, "/test/go-lang.md"); err == nil {
+		t.Error("expected an error for an invalid version")
+	}
+}
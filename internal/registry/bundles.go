@@ -0,0 +1,122 @@
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/howto/internal/config"
+	"github.com/yourusername/howto/internal/parser"
+	"github.com/yourusername/howto/internal/suggest"
+)
+
+// BundlePrefix marks a name as a bundle rather than a playbook, e.g. `howto @backend`
+const BundlePrefix = "@"
+
+// IsBundleName reports whether name refers to a bundle
+func IsBundleName(name string) bool {
+	return strings.HasPrefix(name, BundlePrefix)
+}
+
+// ResolveBundles combines bundle definitions into the bundles served with reg.
+// Definitions are applied in increasing precedence: bundles defined in the
+// front matter of served playbooks, then each config in the order given, so a
+// project config can redefine a bundle from the global config. Playbooks that
+// join a bundle through front matter are appended after the defined members,
+// in List order. Members that are not served are dropped and reported.
+func ResolveBundles(reg Registry, configs ...config.Bundles) (config.Bundles, []Diagnostic) {
+	defined := make(config.Bundles)
+	definedBy := make(map[string]string) // bundle -> file or config that defined it, for diagnostics
+
+	docs := reg.GetAll()
+	for _, doc := range docs {
+		for name, members := range doc.BundleDefs {
+			defined[bundleKey(name)] = members
+			definedBy[bundleKey(name)] = doc.FilePath
+		}
+	}
+	for _, cfg := range configs {
+		for name, members := range cfg {
+			defined[bundleKey(name)] = members
+			definedBy[bundleKey(name)] = ""
+		}
+	}
+	for _, doc := range docs {
+		for _, name := range doc.Bundles {
+			key := bundleKey(name)
+			defined[key] = append(append([]string(nil), defined[key]...), doc.Name)
+		}
+	}
+
+	known := reg.List()
+	bundles := make(config.Bundles, len(defined))
+	var diagnostics []Diagnostic
+	for _, name := range sortedBundleNames(defined) {
+		seen := make(map[string]bool)
+		members := []string{}
+		for _, member := range defined[name] {
+			if seen[member] {
+				continue
+			}
+			seen[member] = true
+
+			if !reg.Has(member) {
+				message := fmt.Sprintf("bundle %s%s lists %q, which is not available", BundlePrefix, name, member)
+				if hint := suggest.Phrase(suggest.Closest(member, known, maxSuggestions)); hint != "" {
+					message += "; " + hint
+				}
+				diagnostics = append(diagnostics, Diagnostic{
+					Severity: SeverityWarning,
+					Name:     BundlePrefix + name,
+					Path:     definedBy[name],
+					Message:  message,
+				})
+				continue
+			}
+			members = append(members, member)
+		}
+		bundles[name] = members
+	}
+
+	return bundles, diagnostics
+}
+
+// bundleKey normalises a bundle name as written in config or front matter
+func bundleKey(name string) string {
+	return strings.TrimPrefix(strings.TrimSpace(name), BundlePrefix)
+}
+
+func sortedBundleNames(bundles config.Bundles) []string {
+	names := make([]string, 0, len(bundles))
+	for name := range bundles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// unknownBundle reports a request for a bundle that is not defined, suggesting close bundle names
+func unknownBundle(name string, bundles config.Bundles) error {
+	candidates := make([]string, 0, len(bundles))
+	for _, bundle := range sortedBundleNames(bundles) {
+		candidates = append(candidates, BundlePrefix+bundle)
+	}
+	return &UnknownPlaybookError{Name: name, Suggestions: suggest.Closest(name, candidates, maxSuggestions)}
+}
+
+// bundleDocuments returns the members of a bundle in order
+func bundleDocuments(reg Registry, bundles config.Bundles, name string) ([]parser.Document, error) {
+	members, ok := bundles[bundleKey(name)]
+	if !ok {
+		return nil, unknownBundle(name, bundles)
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("bundle %s has no available playbooks", name)
+	}
+
+	docs := make([]parser.Document, 0, len(members))
+	for _, member := range members {
+		docs = append(docs, reg[member])
+	}
+	return docs, nil
+}
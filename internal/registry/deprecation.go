@@ -0,0 +1,77 @@
+package registry
+
+import (
+	"fmt"
+
+	"github.com/yourusername/howto/internal/parser"
+	"github.com/yourusername/howto/internal/suggest"
+)
+
+// Listed returns the documents shown in listings, in List order: every document except deprecated ones.
+// Deprecated documents stay fetchable by name.
+func (r Registry) Listed() []parser.Document {
+	return listed(r.GetAll())
+}
+
+func listed(docs []parser.Document) []parser.Document {
+	var kept []parser.Document
+	for _, doc := range docs {
+		if !doc.Deprecated {
+			kept = append(kept, doc)
+		}
+	}
+	return kept
+}
+
+// Replacement follows replaced_by from the named deprecated playbook, through any
+// chain of deprecated replacements, to the playbook that should be served instead.
+// It returns false if the playbook is not deprecated, has no replacement, or the
+// chain ends at a playbook that is not served or loops back on itself.
+func (r Registry) Replacement(name string) (parser.Document, bool) {
+	doc, ok := r[name]
+	seen := map[string]bool{name: true}
+	for ok && doc.Deprecated && doc.ReplacedBy != "" {
+		if seen[doc.ReplacedBy] {
+			return parser.Document{}, false
+		}
+		seen[doc.ReplacedBy] = true
+		doc, ok = r[doc.ReplacedBy]
+		if ok && !doc.Deprecated {
+			return doc, true
+		}
+	}
+	return parser.Document{}, false
+}
+
+// validateReplacements reports deprecated playbooks whose replaced_by target cannot be served
+func validateReplacements(registry Registry) []Diagnostic {
+	var known []string
+	for _, doc := range registry.Listed() {
+		known = append(known, doc.Name)
+	}
+
+	var diagnostics []Diagnostic
+	for _, name := range sortedNames(registry) {
+		doc := registry[name]
+		if !doc.Deprecated || doc.ReplacedBy == "" {
+			continue
+		}
+		if _, ok := registry.Replacement(name); ok {
+			continue
+		}
+
+		message := fmt.Sprintf("deprecated playbook %q is replaced by %q, which is not available", name, doc.ReplacedBy)
+		if target, ok := registry[doc.ReplacedBy]; ok && target.Deprecated {
+			message = fmt.Sprintf("deprecated playbook %q is replaced by %q, which is deprecated without an available replacement", name, doc.ReplacedBy)
+		} else if hint := suggest.Phrase(suggest.Closest(doc.ReplacedBy, known, maxSuggestions)); hint != "" {
+			message += "; " + hint
+		}
+		diagnostics = append(diagnostics, Diagnostic{
+			Severity: SeverityWarning,
+			Name:     name,
+			Path:     doc.FilePath,
+			Message:  message,
+		})
+	}
+	return diagnostics
+}
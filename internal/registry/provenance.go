@@ -0,0 +1,182 @@
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/howto/internal/loader"
+	"github.com/yourusername/howto/internal/parser"
+	"github.com/yourusername/howto/internal/suggest"
+)
+
+// Outcome records what Build decided for a candidate document
+type Outcome string
+
+const (
+	OutcomeServed       Outcome = "served"        // Served under its name
+	OutcomeMerged       Outcome = "merged"        // Served as part of a merged playbook
+	OutcomeOverridden   Outcome = "overridden"    // Replaced by a project playbook with the same name
+	OutcomeNotRequired  Outcome = "not-required"  // required: false and not listed in require
+	OutcomeExcluded     Outcome = "excluded"      // Listed in exclude, directly or through a profile
+	OutcomeFinal        Outcome = "final"         // Ignored because the global playbook is final
+	OutcomeOtherAgent   Outcome = "other-agent"   // Restricted to other agents or replaced by an agent-specific variant
+	OutcomeParseError   Outcome = "parse-error"   // The file could not be parsed
+	OutcomeDuplicate    Outcome = "duplicate"     // Another file in the same library declares the same name
+	OutcomeOtherVersion Outcome = "other-version" // Another version was selected, or the version does not satisfy the pin
+	OutcomeUntrusted    Outcome = "untrusted"     // The project library is not approved with `howto trust`, or changed since
+)
+
+// Candidate is one document Build considered for a playbook name
+type Candidate struct {
+	Source  parser.Source
+	Path    string
+	Version string // Empty for unversioned documents
+	Outcome Outcome
+	Reason  string // Why the document was filtered or how it was combined; empty when served as-is
+}
+
+// Served reports whether the candidate contributes to the served playbook
+func (c Candidate) Served() bool {
+	return c.Outcome == OutcomeServed || c.Outcome == OutcomeMerged
+}
+
+func (c Candidate) String() string {
+	text := fmt.Sprintf("%s %s", c.Source, c.Path)
+	if c.Version != "" {
+		text += " (version " + c.Version + ")"
+	}
+	text += ": " + string(c.Outcome)
+	if c.Reason != "" {
+		text += " (" + c.Reason + ")"
+	}
+	return text
+}
+
+// Provenance lists every candidate document per playbook name, in the order Build considered them.
+// It is never modified after Build returns and may be shared between goroutines.
+type Provenance map[string][]Candidate
+
+// Names returns every name that had at least one candidate
+func (p Provenance) Names() []string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the candidates for name, or an *UnknownPlaybookError with suggestions if none were seen
+func (p Provenance) Lookup(name string) ([]Candidate, error) {
+	if candidates, ok := p[name]; ok {
+		return candidates, nil
+	}
+	return nil, &UnknownPlaybookError{Name: name, Suggestions: suggest.Closest(name, p.Names(), maxSuggestions)}
+}
+
+// provenanceRecorder builds a Provenance while Build runs
+type provenanceRecorder struct {
+	provenance Provenance
+	served     map[string]int // index of the candidate currently served under each name
+}
+
+func newProvenanceRecorder() *provenanceRecorder {
+	return &provenanceRecorder{
+		provenance: make(Provenance),
+		served:     make(map[string]int),
+	}
+}
+
+func (r *provenanceRecorder) record(name string, doc parser.Document, outcome Outcome, reason string) {
+	r.provenance[name] = append(r.provenance[name], Candidate{
+		Source:  doc.Source,
+		Path:    doc.FilePath,
+		Version: doc.Version,
+		Outcome: outcome,
+		Reason:  reason,
+	})
+}
+
+func (r *provenanceRecorder) recordFailures(failures []loader.ParseFailure) {
+	for _, failure := range failures {
+		r.provenance[failure.Name] = append(r.provenance[failure.Name], Candidate{
+			Source:  failure.Source,
+			Path:    failure.Path,
+			Outcome: OutcomeParseError,
+			Reason:  failure.Err.Error(),
+		})
+	}
+}
+
+func (r *provenanceRecorder) recordWithheld(docs []parser.Document) {
+	for _, doc := range docs {
+		r.record(doc.Name, doc, OutcomeUntrusted, "project library withheld until it is reviewed and approved with `howto trust`")
+	}
+}
+
+// serve records doc as the playbook served under its name, demoting the previous one
+func (r *provenanceRecorder) serve(doc parser.Document, merged bool) {
+	if previous, ok := r.served[doc.Name]; ok {
+		candidate := &r.provenance[doc.Name][previous]
+		if merged {
+			candidate.Outcome = OutcomeMerged
+			candidate.Reason = fmt.Sprintf("combined with %s playbook %s (merge: %s)", doc.Source, doc.FilePath, doc.Merge)
+		} else {
+			candidate.Outcome = OutcomeOverridden
+			candidate.Reason = fmt.Sprintf("overridden by %s playbook %s", doc.Source, doc.FilePath)
+		}
+	}
+
+	reason := ""
+	outcome := OutcomeServed
+	if merged {
+		outcome = OutcomeMerged
+		reason = fmt.Sprintf("merge: %s with the global playbook", doc.Merge)
+	}
+	r.record(doc.Name, doc, outcome, reason)
+	r.served[doc.Name] = len(r.provenance[doc.Name]) - 1
+}
+
+// dropDuplicates removes shadowed duplicates from docs, keeping the last one with each
+// name and agents as the library walk always has, and removes ambiguous names entirely
+func (r *provenanceRecorder) dropDuplicates(docs []parser.Document, ambiguous map[string]bool) []parser.Document {
+	last := make(map[string]int)
+	for i, doc := range docs {
+		last[duplicateKey(doc)] = i
+	}
+
+	kept := make([]parser.Document, 0, len(docs))
+	for i, doc := range docs {
+		if ambiguous[doc.Name] {
+			r.record(doc.Name, doc, OutcomeDuplicate, "name is ambiguous and strict mode is on")
+			continue
+		}
+		if winner := last[duplicateKey(doc)]; winner != i {
+			r.record(doc.Name, doc, OutcomeDuplicate, "shadowed by "+docs[winner].FilePath)
+			continue
+		}
+		kept = append(kept, doc)
+	}
+	return kept
+}
+
+// recordAgentFiltered records the docs ForAgent dropped for agent
+func (r *provenanceRecorder) recordAgentFiltered(docs []parser.Document, agent string) {
+	specific := make(map[string]parser.Document)
+	for _, doc := range docs {
+		if len(doc.Agents) > 0 && doc.ForAgent(agent) {
+			specific[doc.Name] = doc
+		}
+	}
+
+	for _, doc := range docs {
+		if !doc.ForAgent(agent) {
+			r.record(doc.Name, doc, OutcomeOtherAgent, "restricted to agents: "+strings.Join(doc.Agents, ", "))
+			continue
+		}
+		if variant, ok := specific[doc.Name]; ok && len(doc.Agents) == 0 {
+			r.record(doc.Name, doc, OutcomeOtherAgent, fmt.Sprintf("replaced by the %q variant %s", agent, variant.FilePath))
+		}
+	}
+}
@@ -1,47 +1,500 @@
 package registry
 
 import (
+	"fmt"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strings"
 
 	"github.com/yourusername/howto/internal/config"
+	"github.com/yourusername/howto/internal/loader"
 	"github.com/yourusername/howto/internal/parser"
+	"github.com/yourusername/howto/internal/semver"
+	"github.com/yourusername/howto/internal/suggest"
+	"github.com/yourusername/howto/internal/xref"
 )
 
 // Registry maps playbook names to their documentation
 type Registry map[string]parser.Document
 
+// Severity classifies a diagnostic
+type Severity string
+
+const (
+	SeverityNotice  Severity = "notice"  // Expected state worth telling the user about, e.g. withheld project playbooks
+	SeverityWarning Severity = "warning" // Problem in a library or config that howto worked around
+	SeverityError   Severity = "error"   // Problem that makes what is served ambiguous
+)
+
+// Problem reports whether the severity marks a problem in the playbook
+// libraries, which strict mode refuses to serve
+func (s Severity) Problem() bool {
+	return s == SeverityWarning || s == SeverityError
+}
+
+// Diagnostic describes a problem found while building the registry
+type Diagnostic struct {
+	Severity Severity
+	Name     string // Playbook name the problem relates to
+	Path     string // File that caused the problem, if known
+	Message  string
+}
+
+func (d Diagnostic) String() string {
+	if d.Path == "" {
+		return d.Message
+	}
+	return fmt.Sprintf("%s (%s)", d.Message, d.Path)
+}
+
 // BuildRegistry creates a unified playbook registry with filtering logic
 // Rules:
 // 1. For both global and project-scoped docs:
 //   - Include if required=true (default)
 //   - Include if required=false AND name is in projectConfig.Require
 //   - Exclude if required=false AND name is NOT in projectConfig.Require
+//   - Exclude if name is in projectConfig.Exclude (set by profiles)
+//
+// 2. If name conflicts: project-scoped overrides global, unless the project doc
+// declares merge: append or merge: prepend, in which case both bodies are
+// combined and each part is marked with the source it came from
 //
-// 2. If name conflicts: project-scoped overrides global
+// 3. A global doc marked final cannot be overridden or excluded; the project doc
+// and the exclude entry are ignored
 func BuildRegistry(globalDocs, projectDocs []parser.Document, projectConfig *config.ProjectConfig) Registry {
+	registry, _, _ := Build(globalDocs, projectDocs, projectConfig, Options{})
+	return registry
+}
+
+// Options tune how Build selects documents
+type Options struct {
+	Agent         string                // Agent identity; docs restricted to other agents are skipped (see ForAgent)
+	ParseFailures []loader.ParseFailure // Files the loader skipped; recorded in the provenance
+	Strict        bool                  // Refuse to serve names that are ambiguous within a library
+	Withheld      []parser.Document     // Project docs withheld because the library is not trusted; recorded in the provenance
+}
+
+// Build applies the same rules as BuildRegistry and also reports the problems it found
+// and the provenance of every playbook name: each candidate document and what happened to it.
+func Build(globalDocs, projectDocs []parser.Document, projectConfig *config.ProjectConfig, opts Options) (Registry, Provenance, []Diagnostic) {
 	registry := make(Registry)
+	recorder := newProvenanceRecorder()
+	var diagnostics []Diagnostic
+
+	// Require entries are validated against every doc, not only those for this agent
+	diagnostics = append(diagnostics, validateRequire(globalDocs, projectDocs, projectConfig)...)
+
+	globalDuplicates := findDuplicates(globalDocs)
+	projectDuplicates := findDuplicates(projectDocs)
+	diagnostics = append(diagnostics, duplicateDiagnostics(globalDuplicates, opts.Strict)...)
+	diagnostics = append(diagnostics, duplicateDiagnostics(projectDuplicates, opts.Strict)...)
+
+	// Each library is only ambiguous about its own duplicates
+	globalDocs = recorder.dropDuplicates(globalDocs, ambiguousNames(globalDuplicates, opts.Strict))
+	projectDocs = recorder.dropDuplicates(projectDocs, ambiguousNames(projectDuplicates, opts.Strict))
+
+	recorder.recordAgentFiltered(globalDocs, opts.Agent)
+	recorder.recordAgentFiltered(projectDocs, opts.Agent)
+	globalDocs = ForAgent(globalDocs, opts.Agent)
+	projectDocs = ForAgent(projectDocs, opts.Agent)
+
+	var versionDiagnostics []Diagnostic
+	globalDocs, versionDiagnostics = selectVersions(recorder, globalDocs, projectConfig)
+	diagnostics = append(diagnostics, versionDiagnostics...)
+	projectDocs, versionDiagnostics = selectVersions(recorder, projectDocs, projectConfig)
+	diagnostics = append(diagnostics, versionDiagnostics...)
+
+	globalByName := make(map[string]parser.Document, len(globalDocs))
+	for _, doc := range globalDocs {
+		globalByName[doc.Name] = doc
+	}
+
+	// contributors tracks the source docs whose bodies end up in each served playbook
+	contributors := make(map[string][]parser.Document)
 
 	// First, add global docs based on filtering rules
 	for _, doc := range globalDocs {
-		// Skip if required=false and not in project config require list
-		if !doc.Required && !projectConfig.HasRequire(doc.Name) {
+		if skipped(recorder, doc, projectConfig) {
 			continue
 		}
+		if doc.Final && projectConfig.HasExclude(doc.Name) {
+			diagnostics = append(diagnostics, Diagnostic{
+				Severity: SeverityWarning,
+				Name:     doc.Name,
+				Path:     doc.FilePath,
+				Message:  fmt.Sprintf("exclude entry %q ignored: global playbook %s is final and cannot be excluded", doc.Name, doc.FilePath),
+			})
+		}
 
 		registry[doc.Name] = doc
+		contributors[doc.Name] = []parser.Document{doc}
+		recorder.serve(doc, false)
 	}
 
 	// Then, add project-scoped docs (they override global docs with same name)
 	for _, doc := range projectDocs {
-		// Skip if required=false and not in project config require list
-		if !doc.Required && !projectConfig.HasRequire(doc.Name) {
+		if skipped(recorder, doc, projectConfig) {
 			continue
 		}
+
+		merged := false
+		if base, ok := globalByName[doc.Name]; ok {
+			if base.Final {
+				diagnostics = append(diagnostics, Diagnostic{
+					Severity: SeverityWarning,
+					Name:     doc.Name,
+					Path:     doc.FilePath,
+					Message:  fmt.Sprintf("project playbook %q ignored: global playbook %s is final and cannot be overridden", doc.Name, base.FilePath),
+				})
+				recorder.record(doc.Name, doc, OutcomeFinal, fmt.Sprintf("global playbook %s is final", base.FilePath))
+				continue
+			}
+			merged = doc.Merge == parser.MergeAppend || doc.Merge == parser.MergePrepend
+			if merged {
+				contributors[doc.Name] = []parser.Document{base, doc}
+			}
+			doc = mergeDocuments(base, doc)
+		}
+		if !merged {
+			contributors[doc.Name] = []parser.Document{doc}
+		}
 		registry[doc.Name] = doc
+		recorder.serve(doc, merged)
 	}
 
-	return registry
+	recorder.recordWithheld(opts.Withheld)
+	recorder.recordFailures(opts.ParseFailures)
+	diagnostics = append(diagnostics, validateLinks(registry, contributors)...)
+	diagnostics = append(diagnostics, validateReplacements(registry)...)
+
+	return registry, recorder.provenance, diagnostics
+}
+
+// skipped applies the require and exclude rules to doc, recording why it was filtered.
+// A final global playbook cannot be excluded, so a project cannot replace it that way.
+func skipped(recorder *provenanceRecorder, doc parser.Document, projectConfig *config.ProjectConfig) bool {
+	// Skip if required=false and not in project config require list
+	if !doc.Required && !projectConfig.HasRequire(doc.Name) {
+		recorder.record(doc.Name, doc, OutcomeNotRequired, "required: false and not listed in require")
+		return true
+	}
+	if projectConfig.HasExclude(doc.Name) && !(doc.Final && doc.Source == parser.SourceGlobal) {
+		recorder.record(doc.Name, doc, OutcomeExcluded, "listed in exclude")
+		return true
+	}
+	return false
+}
+
+// ambiguousNames returns the names strict mode refuses to serve from a library with these duplicates
+func ambiguousNames(duplicates [][]parser.Document, strict bool) map[string]bool {
+	ambiguous := make(map[string]bool)
+	if strict {
+		for _, group := range duplicates {
+			ambiguous[group[0].Name] = true
+		}
+	}
+	return ambiguous
+}
+
+// findDuplicates groups the docs of one library that share a name and target the same agents.
+// Agent-specific variants of a generic playbook are not duplicates.
+func findDuplicates(docs []parser.Document) [][]parser.Document {
+	groups := make(map[string][]parser.Document)
+	var keys []string
+	for _, doc := range docs {
+		key := duplicateKey(doc)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], doc)
+	}
+
+	var duplicates [][]parser.Document
+	for _, key := range keys {
+		if len(groups[key]) > 1 {
+			duplicates = append(duplicates, groups[key])
+		}
+	}
+	return duplicates
+}
+
+// duplicateKey identifies docs that would compete for the same name and agents
+func duplicateKey(doc parser.Document) string {
+	normalized := make([]string, 0, len(doc.Agents))
+	for _, agent := range doc.Agents {
+		normalized = append(normalized, parser.NormalizeAgent(agent))
+	}
+	sort.Strings(normalized)
+	return doc.Name + "\x00" + doc.Version + "\x00" + strings.Join(normalized, ",")
+}
+
+// duplicateDiagnostics reports each group of duplicates with every path involved
+func duplicateDiagnostics(duplicates [][]parser.Document, strict bool) []Diagnostic {
+	var diagnostics []Diagnostic
+	for _, group := range duplicates {
+		paths := make([]string, 0, len(group))
+		for _, doc := range group {
+			paths = append(paths, doc.FilePath)
+		}
+
+		message := fmt.Sprintf("duplicate playbook %q in the %s library: %s", group[0].Name, group[0].Source, strings.Join(paths, ", "))
+		if strict {
+			message += "; not served in strict mode"
+		} else {
+			message += fmt.Sprintf("; serving %s", paths[len(paths)-1])
+		}
+
+		diagnostics = append(diagnostics, Diagnostic{
+			Severity: SeverityError,
+			Name:     group[0].Name,
+			Message:  message,
+		})
+	}
+	return diagnostics
+}
+
+// validateLinks reports [[playbook]] and [[playbook#section]] links in served
+// playbooks whose target is not served or has no such section
+func validateLinks(registry Registry, contributors map[string][]parser.Document) []Diagnostic {
+	known := registry.List()
+	var diagnostics []Diagnostic
+	for _, name := range sortedNames(registry) {
+		for _, doc := range contributors[name] {
+			for _, link := range xref.Find(doc.Content) {
+				var message string
+				if target, ok := registry[link.Target]; !ok {
+					message = fmt.Sprintf("broken link %s: no playbook named %q", link.Raw, link.Target)
+					if hint := suggest.Phrase(suggest.Closest(link.Target, known, maxSuggestions)); hint != "" {
+						message += "; " + hint
+					}
+				} else if link.Section != "" && !xref.Headings(target.Content)[xref.Slug(link.Section)] {
+					message = fmt.Sprintf("broken link %s: playbook %q has no section %q", link.Raw, link.Target, link.Section)
+				} else {
+					continue
+				}
+
+				path := doc.FilePath
+				if doc.BodyLine > 0 {
+					path = fmt.Sprintf("%s:%d", doc.FilePath, doc.BodyLine+link.Line-1)
+				}
+				diagnostics = append(diagnostics, Diagnostic{
+					Severity: SeverityWarning,
+					Name:     name,
+					Path:     path,
+					Message:  message,
+				})
+			}
+		}
+	}
+	return diagnostics
+}
+
+func sortedNames(registry Registry) []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateRequire reports require entries that match no known playbook, suggesting close names
+func validateRequire(globalDocs, projectDocs []parser.Document, projectConfig *config.ProjectConfig) []Diagnostic {
+	known := make(map[string]bool, len(globalDocs)+len(projectDocs))
+	var names []string
+	for _, docs := range [][]parser.Document{globalDocs, projectDocs} {
+		for _, doc := range docs {
+			if !known[doc.Name] {
+				known[doc.Name] = true
+				names = append(names, doc.Name)
+			}
+		}
+	}
+
+	var diagnostics []Diagnostic
+	for _, entry := range projectConfig.Require {
+		name, versionRange := config.SplitRequirement(entry)
+		if versionRange != "" {
+			if _, err := semver.ParseRange(versionRange); err != nil {
+				diagnostics = append(diagnostics, Diagnostic{
+					Severity: SeverityWarning,
+					Name:     name,
+					Message:  fmt.Sprintf("require entry %q: %v; serving the highest version", entry, err),
+				})
+			}
+		}
+		if known[name] {
+			continue
+		}
+
+		message := fmt.Sprintf("require entry %q matches no playbook", name)
+		if hint := suggest.Phrase(suggest.Closest(name, names, 3)); hint != "" {
+			message += "; " + hint
+		}
+
+		diagnostics = append(diagnostics, Diagnostic{
+			Severity: SeverityWarning,
+			Name:     name,
+			Message:  message,
+		})
+	}
+
+	return diagnostics
+}
+
+// ForAgent narrows one library's docs to those meant for the named agent.
+// When a library has both a generic doc and an agent-specific variant with the
+// same name, only the variant is kept for that agent.
+func ForAgent(docs []parser.Document, agent string) []parser.Document {
+	specific := make(map[string]bool)
+	for _, doc := range docs {
+		if len(doc.Agents) > 0 && doc.ForAgent(agent) {
+			specific[doc.Name] = true
+		}
+	}
+
+	filtered := make([]parser.Document, 0, len(docs))
+	for _, doc := range docs {
+		if !doc.ForAgent(agent) {
+			continue
+		}
+		if len(doc.Agents) == 0 && specific[doc.Name] {
+			continue
+		}
+		filtered = append(filtered, doc)
+	}
+	return filtered
+}
+
+// mergeDocuments combines a project doc with the global doc it overrides according to the project doc's merge strategy
+func mergeDocuments(base, override parser.Document) parser.Document {
+	var parts []parser.Document
+	switch override.Merge {
+	case parser.MergeAppend:
+		parts = []parser.Document{base, override}
+	case parser.MergePrepend:
+		parts = []parser.Document{override, base}
+	default:
+		return override
+	}
+
+	sections := make([]string, 0, len(parts))
+	for _, part := range parts {
+		sections = append(sections, sourceMarker(part)+"\n"+part.Content)
+	}
+
+	merged := override
+	merged.Content = strings.Join(sections, "\n\n")
+	// The merged playbook is as stale as its oldest part and owned by everyone who contributed
+	if !base.ReviewBy.IsZero() && (merged.ReviewBy.IsZero() || base.ReviewBy.Before(merged.ReviewBy)) {
+		merged.ReviewBy = base.ReviewBy
+	}
+	merged.Owners = append([]string(nil), override.Owners...)
+	for _, owner := range base.Owners {
+		if !slices.Contains(merged.Owners, owner) {
+			merged.Owners = append(merged.Owners, owner)
+		}
+	}
+	return merged
+}
+
+// sourceMarker returns the comment that labels where a merged section came from
+func sourceMarker(doc parser.Document) string {
+	if doc.FilePath == "" {
+		return fmt.Sprintf("<!-- howto: from %s -->", doc.Source)
+	}
+	return fmt.Sprintf("<!-- howto: from %s (%s) -->", doc.Source, doc.FilePath)
+}
+
+// maxSuggestions caps the number of names suggested for an unknown playbook
+const maxSuggestions = 3
+
+// UnknownPlaybookError reports a lookup for a name that is not in the registry
+type UnknownPlaybookError struct {
+	Name        string
+	Suggestions []string // Closest known names, best first
+}
+
+func (e *UnknownPlaybookError) Error() string {
+	message := fmt.Sprintf("unknown playbook: %s", e.Name)
+	if hint := suggest.Phrase(e.Suggestions); hint != "" {
+		message += "; " + hint
+	}
+	return message
+}
+
+// UnknownPlaybooksError reports every name of a multi-playbook request that did not resolve
+type UnknownPlaybooksError struct {
+	Errors []*UnknownPlaybookError // In the order the names were requested
+}
+
+func (e *UnknownPlaybooksError) Error() string {
+	if len(e.Errors) == 1 {
+		return e.Errors[0].Error()
+	}
+
+	parts := make([]string, 0, len(e.Errors))
+	for _, unknown := range e.Errors {
+		part := unknown.Name
+		if hint := suggest.Phrase(unknown.Suggestions); hint != "" {
+			part += " (" + hint + ")"
+		}
+		parts = append(parts, part)
+	}
+	return "unknown playbooks: " + strings.Join(parts, "; ")
+}
+
+// Lookup retrieves a document by name, returning an *UnknownPlaybookError with suggestions if it does not exist
+func (r Registry) Lookup(name string) (parser.Document, error) {
+	if doc, ok := r[name]; ok {
+		return doc, nil
+	}
+	return parser.Document{}, &UnknownPlaybookError{Name: name, Suggestions: r.Suggest(name)}
+}
+
+// Suggest returns the known names closest to name. Playbooks that list name
+// as an alias come first, followed by edit-distance and prefix matches on
+// names and aliases.
+func (r Registry) Suggest(name string) []string {
+	query := strings.ToLower(strings.TrimSpace(name))
+	var out []string
+	add := func(candidate string) {
+		for _, existing := range out {
+			if existing == candidate {
+				return
+			}
+		}
+		out = append(out, candidate)
+	}
+
+	aliasOwners := make(map[string][]string)
+	var candidates []string
+	for _, docName := range r.List() {
+		candidates = append(candidates, docName)
+		for _, alias := range r[docName].Aliases {
+			if strings.EqualFold(alias, query) {
+				add(docName)
+			}
+			aliasOwners[alias] = append(aliasOwners[alias], docName)
+			candidates = append(candidates, alias)
+		}
+	}
+
+	for _, match := range suggest.Closest(name, candidates, maxSuggestions*2) {
+		if owners, ok := aliasOwners[match]; ok && !r.Has(match) {
+			for _, owner := range owners {
+				add(owner)
+			}
+			continue
+		}
+		add(match)
+	}
+
+	if len(out) > maxSuggestions {
+		out = out[:maxSuggestions]
+	}
+	return out
 }
 
 // Get retrieves a document by name
@@ -50,10 +503,13 @@ func (r Registry) Get(name string) (parser.Document, bool) {
 	return doc, ok
 }
 
-// List returns all document names sorted by their source filenames
+// List returns all document names, most important first: by priority (highest first),
+// then by order (ordered docs first, ascending), then by source filename
 func (r Registry) List() []string {
 	type entry struct {
 		name    string
+		rank    int
+		order   int
 		sortKey string
 	}
 
@@ -66,10 +522,21 @@ func (r Registry) List() []string {
 
 		entries = append(entries, entry{
 			name:    name,
+			rank:    doc.Priority.Rank(),
+			order:   doc.Order,
 			sortKey: sortKey,
 		})
 	}
 	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].rank != entries[j].rank {
+			return entries[i].rank > entries[j].rank
+		}
+		if entries[i].order != entries[j].order {
+			if entries[i].order == 0 || entries[j].order == 0 {
+				return entries[j].order == 0
+			}
+			return entries[i].order < entries[j].order
+		}
 		if entries[i].sortKey == entries[j].sortKey {
 			return entries[i].name < entries[j].name
 		}
@@ -83,7 +550,7 @@ func (r Registry) List() []string {
 	return names
 }
 
-// GetAll returns all documents sorted by filename
+// GetAll returns all documents in List order
 func (r Registry) GetAll() []parser.Document {
 	names := r.List()
 	docs := make([]parser.Document, 0, len(names))
@@ -93,6 +560,22 @@ func (r Registry) GetAll() []parser.Document {
 	return docs
 }
 
+// AtLeast returns the listed documents whose priority is min or higher, in List order.
+// Callers trimming the catalogue to a budget can raise min until it fits.
+func (r Registry) AtLeast(min parser.Priority) []parser.Document {
+	return atLeast(r.Listed(), min)
+}
+
+func atLeast(docs []parser.Document, min parser.Priority) []parser.Document {
+	var kept []parser.Document
+	for _, doc := range docs {
+		if doc.Priority.Rank() >= min.Rank() {
+			kept = append(kept, doc)
+		}
+	}
+	return kept
+}
+
 // Count returns the number of documents in the registry
 func (r Registry) Count() int {
 	return len(r)
@@ -1,9 +1,13 @@
 package registry
 
 import (
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/yourusername/howto/internal/config"
+	"github.com/yourusername/howto/internal/loader"
 	"github.com/yourusername/howto/internal/parser"
 )
 
@@ -218,6 +222,31 @@ func TestRegistry_List(t *testing.T) {
 	}
 }
 
+func TestRegistry_ListByPriorityAndOrder(t *testing.T) {
+	registry := Registry{
+		"style":    {Name: "style", FilePath: "a-style.md", Priority: parser.PriorityLow},
+		"commits":  {Name: "commits", FilePath: "b-commits.md"},
+		"go-lang":  {Name: "go-lang", FilePath: "c-go-lang.md", Order: 2},
+		"testing":  {Name: "testing", FilePath: "d-testing.md", Order: 1},
+		"security": {Name: "security", FilePath: "z-security.md", Priority: parser.PriorityCritical},
+		"review":   {Name: "review", FilePath: "e-review.md", Priority: parser.PriorityHigh},
+	}
+
+	expected := []string{"security", "review", "testing", "go-lang", "commits", "style"}
+	names := registry.List()
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+
+	var important []string
+	for _, doc := range registry.AtLeast(parser.PriorityHigh) {
+		important = append(important, doc.Name)
+	}
+	if strings.Join(important, ",") != "security,review" {
+		t.Errorf("expected security and review at high priority or above, got %v", important)
+	}
+}
+
 func TestRegistry_GetAll(t *testing.T) {
 	projectDocs := []parser.Document{
 		{Name: "zebra", Description: "Z", Required: true, Source: parser.SourceProjectScoped, FilePath: "2-zebra.md"},
@@ -372,3 +401,548 @@ func TestRegistry_Count(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildRegistry_ExcludedByConfig(t *testing.T) {
+	globalDocs := []parser.Document{
+		{Name: "go-lang", Description: "Go rules", Required: true, Source: parser.SourceGlobal},
+		{Name: "review-guide", Description: "Review rules", Required: true, Source: parser.SourceGlobal},
+	}
+
+	registry := BuildRegistry(globalDocs, nil, &config.ProjectConfig{Exclude: []string{"review-guide"}})
+
+	if !registry.Has("go-lang") {
+		t.Error("expected go-lang doc to be in registry")
+	}
+	if registry.Has("review-guide") {
+		t.Error("did not expect excluded review-guide doc in registry")
+	}
+}
+
+func TestBuildRegistry_ProjectMergeStrategies(t *testing.T) {
+	globalDocs := []parser.Document{
+		{Name: "go-lang", Description: "Global Go rules", Content: "Global rules", Required: true, Source: parser.SourceGlobal, FilePath: "global/go-lang.md"},
+	}
+
+	tests := []struct {
+		merge parser.MergeStrategy
+		first string
+		last  string
+	}{
+		{parser.MergeAppend, "Global rules", "Project rules"},
+		{parser.MergePrepend, "Project rules", "Global rules"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.merge), func(t *testing.T) {
+			projectDocs := []parser.Document{
+				{Name: "go-lang", Description: "Project Go rules", Content: "Project rules", Required: true, Merge: tt.merge, Source: parser.SourceProjectScoped, FilePath: ".howto/go-lang.md"},
+			}
+
+			registry := BuildRegistry(globalDocs, projectDocs, &config.ProjectConfig{})
+			doc, ok := registry.Get("go-lang")
+			if !ok {
+				t.Fatal("expected go-lang doc to exist")
+			}
+
+			firstPos := strings.Index(doc.Content, tt.first)
+			lastPos := strings.Index(doc.Content, tt.last)
+			if firstPos == -1 || lastPos == -1 || firstPos > lastPos {
+				t.Fatalf("expected %q before %q, got:\n%s", tt.first, tt.last, doc.Content)
+			}
+			if !strings.Contains(doc.Content, "<!-- howto: from global (global/go-lang.md) -->") {
+				t.Errorf("expected global source marker, got:\n%s", doc.Content)
+			}
+			if !strings.Contains(doc.Content, "<!-- howto: from project (.howto/go-lang.md) -->") {
+				t.Errorf("expected project source marker, got:\n%s", doc.Content)
+			}
+		})
+	}
+}
+
+func TestBuild_FinalGlobalCannotBeOverridden(t *testing.T) {
+	globalDocs := []parser.Document{
+		{Name: "security-checklist", Description: "Company rules", Content: "Company rules", Required: true, Final: true, Source: parser.SourceGlobal, FilePath: "global/security-checklist.md"},
+	}
+	projectDocs := []parser.Document{
+		{Name: "security-checklist", Description: "Relaxed rules", Content: "Anything goes", Required: true, Source: parser.SourceProjectScoped, FilePath: ".howto/security-checklist.md"},
+	}
+
+	registry, _, diagnostics := Build(globalDocs, projectDocs, &config.ProjectConfig{}, Options{})
+
+	doc, ok := registry.Get("security-checklist")
+	if !ok {
+		t.Fatal("expected security-checklist doc to exist")
+	}
+	if doc.Source != parser.SourceGlobal || doc.Content != "Company rules" {
+		t.Errorf("expected final global doc to be kept, got %+v", doc)
+	}
+
+	if len(diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %d", len(diagnostics))
+	}
+	if diagnostics[0].Path != ".howto/security-checklist.md" || !strings.Contains(diagnostics[0].Message, "final") {
+		t.Errorf("unexpected diagnostic: %+v", diagnostics[0])
+	}
+}
+
+func TestBuild_FinalGlobalCannotBeExcluded(t *testing.T) {
+	globalDocs := []parser.Document{
+		{Name: "security-checklist", Description: "Company rules", Content: "Company rules", Required: true, Final: true, Source: parser.SourceGlobal, FilePath: "global/security-checklist.md"},
+	}
+	projectDocs := []parser.Document{
+		{Name: "security-checklist", Description: "Relaxed rules", Content: "Anything goes", Required: true, Source: parser.SourceProjectScoped, FilePath: ".howto/security-checklist.md"},
+	}
+	projectConfig := &config.ProjectConfig{Profiles: map[string]config.Profile{"fast": {Exclude: []string{"security-checklist"}}}}
+	projectConfig, err := projectConfig.WithProfile("fast")
+	if err != nil {
+		t.Fatalf("WithProfile() failed: %v", err)
+	}
+
+	registry, provenance, diagnostics := Build(globalDocs, projectDocs, projectConfig, Options{})
+
+	doc, ok := registry.Get("security-checklist")
+	if !ok || doc.Source != parser.SourceGlobal || doc.Content != "Company rules" {
+		t.Fatalf("expected the final global doc to be served despite the exclude, got %+v", doc)
+	}
+	if len(diagnostics) != 1 || diagnostics[0].Path != "global/security-checklist.md" || !strings.Contains(diagnostics[0].Message, "cannot be excluded") {
+		t.Fatalf("expected a diagnostic for the ignored exclude entry, got %+v", diagnostics)
+	}
+
+	candidates := provenance["security-checklist"]
+	if len(candidates) != 2 || candidates[0].Outcome != OutcomeServed || candidates[1].Outcome == OutcomeServed {
+		t.Errorf("expected only the global doc to be served, got %+v", candidates)
+	}
+}
+
+func TestBuild_UnknownRequireSuggestsCorrection(t *testing.T) {
+	globalDocs := []parser.Document{
+		{Name: "optional-rule", Description: "Optional rule", Required: false, Source: parser.SourceGlobal},
+	}
+
+	_, _, diagnostics := Build(globalDocs, nil, &config.ProjectConfig{Require: []string{"optinal-rule", "optional-rule"}}, Options{})
+
+	if len(diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %d: %+v", len(diagnostics), diagnostics)
+	}
+	if diagnostics[0].Name != "optinal-rule" {
+		t.Errorf("expected diagnostic for optinal-rule, got %q", diagnostics[0].Name)
+	}
+	if !strings.Contains(diagnostics[0].Message, `did you mean "optional-rule"?`) {
+		t.Errorf("expected suggestion in message, got %q", diagnostics[0].Message)
+	}
+}
+
+func TestBuild_AgentVariants(t *testing.T) {
+	globalDocs := []parser.Document{
+		{Name: "testing", Description: "Generic testing", Content: "Run go test.", Required: true, Source: parser.SourceGlobal},
+		{Name: "testing", Description: "Runner testing", Content: "Use the built-in test runner.", Required: true, Agents: []string{"Runner-Agent"}, Source: parser.SourceGlobal},
+		{Name: "runner-tips", Description: "Runner tips", Required: true, Agents: []string{"runner-agent"}, Source: parser.SourceGlobal},
+	}
+
+	tests := []struct {
+		agent      string
+		content    string
+		runnerTips bool
+	}{
+		{"", "Run go test.", false},
+		{"other-agent", "Run go test.", false},
+		{"runner-agent", "Use the built-in test runner.", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.agent, func(t *testing.T) {
+			registry, _, _ := Build(globalDocs, nil, &config.ProjectConfig{}, Options{Agent: tt.agent})
+
+			doc, ok := registry.Get("testing")
+			if !ok {
+				t.Fatal("expected testing doc to exist")
+			}
+			if doc.Content != tt.content {
+				t.Errorf("expected content %q, got %q", tt.content, doc.Content)
+			}
+			if registry.Has("runner-tips") != tt.runnerTips {
+				t.Errorf("expected runner-tips presence %v", tt.runnerTips)
+			}
+		})
+	}
+}
+
+func TestRegistry_LookupSuggestsNames(t *testing.T) {
+	reg := Registry{
+		"go-lang":       {Name: "go-lang", Aliases: []string{"golang"}},
+		"commits":       {Name: "commits"},
+		"db-migrations": {Name: "db-migrations"},
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "golang", want: "go-lang"},   // alias
+		{query: "comits", want: "commits"},   // edit distance
+		{query: "db", want: "db-migrations"}, // prefix
+		{query: "GoLang", want: "go-lang"},   // alias, case-insensitive
+	}
+	for _, tt := range tests {
+		_, err := reg.Lookup(tt.query)
+		var unknown *UnknownPlaybookError
+		if !errors.As(err, &unknown) {
+			t.Fatalf("Lookup(%q): expected UnknownPlaybookError, got %v", tt.query, err)
+		}
+		if len(unknown.Suggestions) == 0 || unknown.Suggestions[0] != tt.want {
+			t.Errorf("Lookup(%q): expected %q first, got %v", tt.query, tt.want, unknown.Suggestions)
+		}
+		if !strings.Contains(err.Error(), `did you mean "`+tt.want+`"`) {
+			t.Errorf("Lookup(%q): expected suggestion in message, got %q", tt.query, err)
+		}
+	}
+
+	if _, err := reg.Lookup("kubernetes"); err == nil || strings.Contains(err.Error(), "did you mean") {
+		t.Errorf("expected no suggestions for an unrelated name, got %v", err)
+	}
+	if doc, err := reg.Lookup("commits"); err != nil || doc.Name != "commits" {
+		t.Errorf("expected Lookup to return known playbooks, got %v, %v", doc, err)
+	}
+}
+
+func TestBuild_Provenance(t *testing.T) {
+	globalDocs := []parser.Document{
+		{Name: "go-lang", Required: true, Source: parser.SourceGlobal, FilePath: "/global/go-lang.md"},
+		{Name: "optional-rule", Required: false, Source: parser.SourceGlobal, FilePath: "/global/optional-rule.md"},
+		{Name: "security", Required: true, Final: true, Source: parser.SourceGlobal, FilePath: "/global/security.md"},
+		{Name: "commits", Required: true, Source: parser.SourceGlobal, FilePath: "/global/commits.md"},
+		{Name: "review", Required: true, Agents: []string{"other-agent"}, Source: parser.SourceGlobal, FilePath: "/global/review.md"},
+	}
+	projectDocs := []parser.Document{
+		{Name: "go-lang", Required: true, Source: parser.SourceProjectScoped, FilePath: "/project/go-lang.md"},
+		{Name: "security", Required: true, Source: parser.SourceProjectScoped, FilePath: "/project/security.md"},
+		{Name: "commits", Required: true, Merge: parser.MergeAppend, Source: parser.SourceProjectScoped, FilePath: "/project/commits.md"},
+		{Name: "legacy", Required: true, Source: parser.SourceProjectScoped, FilePath: "/project/legacy.md"},
+	}
+	cfg := &config.ProjectConfig{Exclude: []string{"legacy"}}
+	failures := []loader.ParseFailure{
+		{Name: "broken", Path: "/project/broken.md", Source: parser.SourceProjectScoped, Err: errors.New("missing front matter")},
+	}
+
+	_, provenance, _ := Build(globalDocs, projectDocs, cfg, Options{ParseFailures: failures})
+
+	tests := []struct {
+		name     string
+		outcomes []Outcome
+	}{
+		{name: "go-lang", outcomes: []Outcome{OutcomeOverridden, OutcomeServed}},
+		{name: "optional-rule", outcomes: []Outcome{OutcomeNotRequired}},
+		{name: "security", outcomes: []Outcome{OutcomeServed, OutcomeFinal}},
+		{name: "commits", outcomes: []Outcome{OutcomeMerged, OutcomeMerged}},
+		{name: "review", outcomes: []Outcome{OutcomeOtherAgent}},
+		{name: "legacy", outcomes: []Outcome{OutcomeExcluded}},
+		{name: "broken", outcomes: []Outcome{OutcomeParseError}},
+	}
+	for _, tt := range tests {
+		candidates := provenance[tt.name]
+		if len(candidates) != len(tt.outcomes) {
+			t.Errorf("%s: expected %d candidates, got %+v", tt.name, len(tt.outcomes), candidates)
+			continue
+		}
+		for i, outcome := range tt.outcomes {
+			if candidates[i].Outcome != outcome {
+				t.Errorf("%s: candidate %d: expected %s, got %s", tt.name, i, outcome, candidates[i].Outcome)
+			}
+		}
+	}
+
+	if reason := provenance["go-lang"][0].Reason; !strings.Contains(reason, "/project/go-lang.md") {
+		t.Errorf("expected overridden reason to name the project file, got %q", reason)
+	}
+	if _, err := provenance.Lookup("go-lnag"); err == nil || !strings.Contains(err.Error(), `did you mean "go-lang"?`) {
+		t.Errorf("expected suggestion for unknown name, got %v", err)
+	}
+}
+
+func TestBuild_DuplicateNamesInLibrary(t *testing.T) {
+	globalDocs := []parser.Document{
+		{Name: "deploy", Required: true, Content: "Global", Source: parser.SourceGlobal, FilePath: "/global/deploy.md"},
+	}
+	projectDocs := []parser.Document{
+		{Name: "deploy", Required: true, Content: "Outdated", Source: parser.SourceProjectScoped, FilePath: "/project/old/deploy.md"},
+		{Name: "deploy", Required: true, Content: "Current", Source: parser.SourceProjectScoped, FilePath: "/project/new/deploy.md"},
+		{Name: "review", Required: true, Source: parser.SourceProjectScoped, FilePath: "/project/review.md"},
+		{Name: "review", Required: true, Agents: []string{"codex"}, Source: parser.SourceProjectScoped, FilePath: "/project/review.codex.md"},
+	}
+
+	registry, provenance, diagnostics := Build(globalDocs, projectDocs, &config.ProjectConfig{}, Options{})
+	if len(diagnostics) != 1 {
+		t.Fatalf("expected one diagnostic (agent variants are not duplicates), got %v", diagnostics)
+	}
+	message := diagnostics[0].Message
+	if !strings.Contains(message, "/project/old/deploy.md") || !strings.Contains(message, "/project/new/deploy.md") {
+		t.Errorf("expected both paths in diagnostic, got %q", message)
+	}
+	if registry["deploy"].Content != "Current" {
+		t.Errorf("expected the last duplicate to be served, got %q", registry["deploy"].Content)
+	}
+	if outcome := provenance["deploy"][0].Outcome; outcome != OutcomeDuplicate {
+		t.Errorf("expected shadowed duplicate in provenance, got %s", outcome)
+	}
+
+	registry, _, diagnostics = Build(globalDocs, projectDocs, &config.ProjectConfig{}, Options{Strict: true})
+	if registry["deploy"].Content != "Global" {
+		t.Errorf("expected the ambiguous project copies to be withheld in strict mode, leaving the global playbook, got %q", registry["deploy"].Content)
+	}
+	if !registry.Has("review") {
+		t.Error("expected unambiguous playbooks to be served in strict mode")
+	}
+	if len(diagnostics) != 1 || !strings.Contains(diagnostics[0].Message, "strict mode") {
+		t.Errorf("expected strict mode diagnostic, got %v", diagnostics)
+	}
+}
+
+func TestBuild_StrictDuplicatesStayInTheirLibrary(t *testing.T) {
+	globalDocs := []parser.Document{
+		{Name: "deploy", Required: true, Content: "Old global", Source: parser.SourceGlobal, FilePath: "/global/old/deploy.md"},
+		{Name: "deploy", Required: true, Content: "New global", Source: parser.SourceGlobal, FilePath: "/global/new/deploy.md"},
+	}
+	projectDocs := []parser.Document{
+		{Name: "deploy", Required: true, Content: "Project", Source: parser.SourceProjectScoped, FilePath: "/project/deploy.md"},
+	}
+
+	registry, provenance, diagnostics := Build(globalDocs, projectDocs, &config.ProjectConfig{}, Options{Strict: true})
+	if registry["deploy"].Content != "Project" {
+		t.Errorf("expected the project's single override to be served despite the global duplicates, got %q", registry["deploy"].Content)
+	}
+	if len(diagnostics) != 1 || !strings.Contains(diagnostics[0].Message, "global library") {
+		t.Errorf("expected one diagnostic for the global duplicates, got %v", diagnostics)
+	}
+	if candidates := provenance["deploy"]; len(candidates) != 3 || candidates[0].Outcome != OutcomeDuplicate || candidates[1].Outcome != OutcomeDuplicate || candidates[2].Outcome != OutcomeServed {
+		t.Errorf("expected both global copies withheld and the project copy served, got %+v", candidates)
+	}
+}
+
+func TestSnapshot_RevisionAndHashes(t *testing.T) {
+	reg := Registry{
+		"go-lang": {Name: "go-lang", Description: "Go", Content: "Handle errors."},
+		"commits": {Name: "commits", Description: "Commits", Content: "Be brief."},
+	}
+
+	first := NewSnapshot(reg)
+	second := NewSnapshot(Registry{
+		"commits": reg["commits"],
+		"go-lang": reg["go-lang"],
+	})
+	if first.Revision() == "" || first.Revision() != second.Revision() {
+		t.Fatalf("expected equal content to share a revision, got %q and %q", first.Revision(), second.Revision())
+	}
+	if first.Hash("go-lang") != DocumentHash(reg["go-lang"]) || first.Hash("missing") != "" {
+		t.Errorf("unexpected document hashes: %q, %q", first.Hash("go-lang"), first.Hash("missing"))
+	}
+
+	// Changing the source registry after the snapshot is taken must not leak into it
+	changed := reg["go-lang"]
+	changed.Content = "Wrap errors."
+	reg["go-lang"] = changed
+	if doc, _ := first.Get("go-lang"); doc.Content != "Handle errors." {
+		t.Errorf("expected snapshot to be immutable, got %q", doc.Content)
+	}
+
+	third := NewSnapshot(reg)
+	if third.Revision() == first.Revision() {
+		t.Error("expected a content change to produce a new revision")
+	}
+	if third.Hash("commits") != first.Hash("commits") {
+		t.Error("expected unchanged documents to keep their hash")
+	}
+
+	base := DocumentHash(reg["commits"])
+	for field, change := range map[string]func(*parser.Document){
+		"version":     func(doc *parser.Document) { doc.Version = "2.0.0" },
+		"priority":    func(doc *parser.Document) { doc.Priority = parser.PriorityCritical },
+		"order":       func(doc *parser.Document) { doc.Order = 1 },
+		"tags":        func(doc *parser.Document) { doc.Tags = []string{"git"} },
+		"agents":      func(doc *parser.Document) { doc.Agents = []string{"codex"} },
+		"deprecated":  func(doc *parser.Document) { doc.Deprecated = true },
+		"replaced_by": func(doc *parser.Document) { doc.ReplacedBy = "git-commits" },
+	} {
+		doc := reg["commits"]
+		change(&doc)
+		if DocumentHash(doc) == base {
+			t.Errorf("expected a %s change to produce a new hash", field)
+		}
+	}
+}
+
+func TestResolveBundles(t *testing.T) {
+	reg := Registry{
+		"index":   {Name: "index", BundleDefs: map[string][]string{"backend": {"go-lang"}, "review": {"commits"}}},
+		"go-lang": {Name: "go-lang", Content: "Handle errors."},
+		"commits": {Name: "commits", Content: "Be brief."},
+		"db":      {Name: "db", Content: "Migrate.", Bundles: []string{"backend"}},
+	}
+
+	bundles, diagnostics := ResolveBundles(reg, config.Bundles{"backend": {"commits", "go-lang", "go-lnag"}})
+	if got := strings.Join(bundles["backend"], ","); got != "commits,go-lang,db" {
+		t.Errorf("expected config members followed by front matter members, got %s", got)
+	}
+	if got := strings.Join(bundles["review"], ","); got != "commits" {
+		t.Errorf("expected front matter definition to be kept, got %s", got)
+	}
+	if len(diagnostics) != 1 || !strings.Contains(diagnostics[0].Message, `did you mean "go-lang"?`) {
+		t.Errorf("expected a diagnostic for the unknown member, got %v", diagnostics)
+	}
+
+	snapshot := NewSnapshot(reg).WithBundles(bundles)
+	docs, err := snapshot.Resolve("@backend")
+	if err != nil || len(docs) != 3 || docs[0].Name != "commits" {
+		t.Fatalf("unexpected bundle resolution: %+v, %v", docs, err)
+	}
+	if snapshot.Revision() == NewSnapshot(reg).Revision() {
+		t.Error("expected bundles to contribute to the revision")
+	}
+	if _, err := snapshot.Resolve("@backnd"); err == nil || !strings.Contains(err.Error(), `did you mean "@backend"?`) {
+		t.Errorf("expected suggestion for unknown bundle, got %v", err)
+	}
+}
+
+func TestBuild_ValidatesLinks(t *testing.T) {
+	globalDocs := []parser.Document{
+		{Name: "commits", Required: true, Content: "# Commits\n\n## Message Format\nBe brief.", FilePath: "/global/commits.md", BodyLine: 5},
+		{Name: "go-lang", Required: true, Content: "Follow [[commits#Message Format]].\nSee [[comits]].\nAnd [[commits#Signing]].", FilePath: "/global/go-lang.md", BodyLine: 5},
+	}
+
+	_, _, diagnostics := Build(globalDocs, nil, &config.ProjectConfig{}, Options{})
+	if len(diagnostics) != 2 {
+		t.Fatalf("expected 2 broken links, got %v", diagnostics)
+	}
+
+	if diagnostics[0].Path != "/global/go-lang.md:6" || !strings.Contains(diagnostics[0].Message, `did you mean "commits"?`) {
+		t.Errorf("unexpected diagnostic for unknown target: %v", diagnostics[0])
+	}
+	if diagnostics[1].Path != "/global/go-lang.md:7" || !strings.Contains(diagnostics[1].Message, `no section "Signing"`) {
+		t.Errorf("unexpected diagnostic for unknown section: %v", diagnostics[1])
+	}
+}
+
+func TestBuild_Deprecation(t *testing.T) {
+	globalDocs := []parser.Document{
+		{Name: "go-lang", Required: true, Content: "New rules", FilePath: "/global/go-lang.md"},
+		{Name: "golang", Required: true, Deprecated: true, ReplacedBy: "go-lang", FilePath: "/global/golang.md"},
+		{Name: "go", Required: true, Deprecated: true, ReplacedBy: "golang", FilePath: "/global/go.md"},
+		{Name: "gopher", Required: true, Deprecated: true, ReplacedBy: "go-lagn", FilePath: "/global/gopher.md"},
+	}
+
+	reg, _, diagnostics := Build(globalDocs, nil, &config.ProjectConfig{}, Options{})
+	if len(diagnostics) != 1 || diagnostics[0].Name != "gopher" || !strings.Contains(diagnostics[0].Message, `did you mean "go-lang"?`) {
+		t.Fatalf("expected one warning for the unavailable replacement, got %v", diagnostics)
+	}
+
+	if listed := reg.Listed(); len(listed) != 1 || listed[0].Name != "go-lang" {
+		t.Errorf("expected only go-lang to be listed, got %v", listed)
+	}
+	if !reg.Has("golang") {
+		t.Error("expected deprecated playbook to stay fetchable")
+	}
+	if replacement, ok := reg.Replacement("go"); !ok || replacement.Name != "go-lang" {
+		t.Errorf("expected go to be redirected to go-lang through golang, got %v, %v", replacement.Name, ok)
+	}
+	if _, ok := reg.Replacement("gopher"); ok {
+		t.Error("expected no replacement for an unavailable target")
+	}
+	if _, ok := reg.Replacement("go-lang"); ok {
+		t.Error("expected no replacement for a current playbook")
+	}
+}
+
+func TestBuild_MergeKeepsOldestReviewDate(t *testing.T) {
+	globalDocs := []parser.Document{
+		{Name: "deploy", Required: true, Owners: []string{"@ops"}, ReviewBy: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Source: parser.SourceGlobal, FilePath: "/global/deploy.md"},
+	}
+	projectDocs := []parser.Document{
+		{Name: "deploy", Required: true, Merge: parser.MergeAppend, Owners: []string{"@web", "@ops"}, ReviewBy: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), Source: parser.SourceProjectScoped, FilePath: "/project/deploy.md"},
+	}
+
+	reg := BuildRegistry(globalDocs, projectDocs, &config.ProjectConfig{})
+	doc := reg["deploy"]
+	if !doc.ReviewBy.Equal(globalDocs[0].ReviewBy) {
+		t.Errorf("expected the global review date to win, got %v", doc.ReviewBy)
+	}
+	if len(doc.Owners) != 2 || doc.Owners[0] != "@web" || doc.Owners[1] != "@ops" {
+		t.Errorf("expected owners of both parts, got %v", doc.Owners)
+	}
+}
+
+func TestBuild_SelectsVersions(t *testing.T) {
+	globalDocs := []parser.Document{
+		{Name: "go-lang", Required: true, Version: "1.0.0", Content: "v1", Source: parser.SourceGlobal, FilePath: "/global/go-lang@1.md"},
+		{Name: "go-lang", Required: true, Version: "2.3.0", Content: "v2.3", Source: parser.SourceGlobal, FilePath: "/global/go-lang@2.3.md"},
+		{Name: "go-lang", Required: true, Version: "3.0.0", Content: "v3", Source: parser.SourceGlobal, FilePath: "/global/go-lang@3.md"},
+		{Name: "commits", Required: true, Version: "1.0.0", Content: "c1", Source: parser.SourceGlobal, FilePath: "/global/commits@1.md"},
+	}
+
+	reg, _, diagnostics := Build(globalDocs, nil, &config.ProjectConfig{}, Options{})
+	if len(diagnostics) != 0 {
+		t.Fatalf("expected versions not to be reported as duplicates, got %v", diagnostics)
+	}
+	if reg["go-lang"].Version != "3.0.0" {
+		t.Errorf("expected the highest version without a pin, got %s", reg["go-lang"].Version)
+	}
+
+	pinned := &config.ProjectConfig{Require: []string{"go-lang@^2", "commits@^2"}}
+	reg, provenance, diagnostics := Build(globalDocs, nil, pinned, Options{})
+	if reg["go-lang"].Version != "2.3.0" {
+		t.Errorf("expected go-lang@^2 to resolve to 2.3.0, got %s", reg["go-lang"].Version)
+	}
+	if reg.Has("commits") {
+		t.Error("expected commits not to be served when no version satisfies the pin")
+	}
+	if len(diagnostics) != 1 || !strings.Contains(diagnostics[0].Message, "commits@^2 matches no version in the global library (available: 1.0.0)") {
+		t.Errorf("expected a warning for the unsatisfied pin, got %v", diagnostics)
+	}
+
+	candidates := provenance["go-lang"]
+	if len(candidates) != 3 || candidates[0].Outcome != OutcomeOtherVersion || candidates[2].Version != "2.3.0" || !candidates[2].Served() {
+		t.Errorf("unexpected provenance: %v", candidates)
+	}
+}
+
+func TestBuild_UnversionedProjectOverrideIgnoresPin(t *testing.T) {
+	globalDocs := []parser.Document{
+		{Name: "go-lang", Required: true, Version: "2.0.0", Content: "v2", Source: parser.SourceGlobal, FilePath: "/global/go-lang@2.md"},
+	}
+	projectDocs := []parser.Document{
+		{Name: "go-lang", Required: true, Merge: parser.MergeAppend, Content: "Project rules", Source: parser.SourceProjectScoped, FilePath: "/project/go-lang.md"},
+	}
+
+	reg := BuildRegistry(globalDocs, projectDocs, &config.ProjectConfig{Require: []string{"go-lang@^2"}})
+	if doc := reg["go-lang"]; !strings.Contains(doc.Content, "v2") || !strings.Contains(doc.Content, "Project rules") {
+		t.Errorf("expected the project doc merged with version 2, got %q", doc.Content)
+	}
+}
+
+func TestSnapshot_ResolveAll(t *testing.T) {
+	reg := Registry{
+		"go-lang": {Name: "go-lang"},
+		"commits": {Name: "commits"},
+		"testing": {Name: "testing"},
+	}
+	snapshot := NewSnapshot(reg).WithBundles(config.Bundles{"backend": {"go-lang", "commits"}})
+
+	docs, err := snapshot.ResolveAll([]string{"testing", "go-lang", "@backend", "testing"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var names []string
+	for _, doc := range docs {
+		names = append(names, doc.Name)
+	}
+	if strings.Join(names, ",") != "testing,go-lang,commits" {
+		t.Errorf("expected deduplicated playbooks in request order, got %v", names)
+	}
+
+	_, err = snapshot.ResolveAll([]string{"go-lan", "commits", "testin", "go-lan"})
+	var unknown *UnknownPlaybooksError
+	if !errors.As(err, &unknown) || len(unknown.Errors) != 2 {
+		t.Fatalf("expected both unknown names to be reported, got %v", err)
+	}
+	if expected := `unknown playbooks: go-lan (did you mean "go-lang"?); testin (did you mean "testing"?)`; err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+}
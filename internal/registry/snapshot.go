@@ -0,0 +1,226 @@
+package registry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/howto/internal/config"
+	"github.com/yourusername/howto/internal/parser"
+)
+
+// hashLength is the number of hex characters kept from content hashes
+const hashLength = 16
+
+// Snapshot is an immutable view of a built registry. It is safe to share
+// between goroutines and callers, so loaders can hand out the same snapshot
+// instead of copying the registry on every request.
+//
+// Each snapshot carries a revision derived from the content it serves and a
+// hash per document, so clients can tell whether the instructions they hold
+// are still current.
+type Snapshot struct {
+	docs     Registry
+	names    []string // List order, computed once
+	hashes   map[string]string
+	bundles  config.Bundles
+	revision string
+}
+
+// NewSnapshot freezes a copy of reg
+func NewSnapshot(reg Registry) *Snapshot {
+	docs := make(Registry, len(reg))
+	for name, doc := range reg {
+		docs[name] = doc
+	}
+
+	snapshot := &Snapshot{
+		docs:   docs,
+		names:  docs.List(),
+		hashes: make(map[string]string, len(docs)),
+	}
+	for name, doc := range docs {
+		snapshot.hashes[name] = DocumentHash(doc)
+	}
+	snapshot.revision = snapshot.computeRevision()
+
+	return snapshot
+}
+
+// WithBundles returns a copy of the snapshot that also serves the given bundles (see ResolveBundles)
+func (s *Snapshot) WithBundles(bundles config.Bundles) *Snapshot {
+	dest := *s
+	dest.bundles = make(config.Bundles, len(bundles))
+	for name, members := range bundles {
+		dest.bundles[name] = append([]string(nil), members...)
+	}
+	dest.revision = dest.computeRevision()
+	return &dest
+}
+
+// computeRevision hashes the document hashes and bundle definitions in a stable order
+func (s *Snapshot) computeRevision() string {
+	names := make([]string, 0, len(s.hashes))
+	for name := range s.hashes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	revision := sha256.New()
+	for _, name := range names {
+		revision.Write([]byte(name))
+		revision.Write([]byte{0})
+		revision.Write([]byte(s.hashes[name]))
+		revision.Write([]byte{0})
+	}
+	for _, name := range sortedBundleNames(s.bundles) {
+		revision.Write([]byte(BundlePrefix + name))
+		revision.Write([]byte{0})
+		revision.Write([]byte(strings.Join(s.bundles[name], ",")))
+		revision.Write([]byte{0})
+	}
+	return hex.EncodeToString(revision.Sum(nil))[:hashLength]
+}
+
+// DocumentHash hashes what an agent receives for a document: its name,
+// description and content, and the metadata that changes how it is served
+// (version, priority, order, tags, agents and deprecation)
+func DocumentHash(doc parser.Document) string {
+	deprecated := ""
+	if doc.Deprecated {
+		deprecated = "deprecated"
+	}
+
+	hasher := sha256.New()
+	for _, part := range []string{
+		doc.Name, doc.Description, doc.Content,
+		doc.Version, string(doc.Priority), strconv.Itoa(doc.Order),
+		strings.Join(doc.Tags, "\x1f"), strings.Join(doc.Agents, "\x1f"),
+		deprecated, doc.ReplacedBy, doc.DeprecationNote,
+	} {
+		hasher.Write([]byte(part))
+		hasher.Write([]byte{0})
+	}
+	return hex.EncodeToString(hasher.Sum(nil))[:hashLength]
+}
+
+// Revision identifies the snapshot's content. Snapshots serving the same documents share a revision.
+func (s *Snapshot) Revision() string {
+	return s.revision
+}
+
+// Hash returns the content hash of the named document, or "" if it is not in the snapshot
+func (s *Snapshot) Hash(name string) string {
+	return s.hashes[name]
+}
+
+// Get retrieves a document by name
+func (s *Snapshot) Get(name string) (parser.Document, bool) {
+	return s.docs.Get(name)
+}
+
+// Lookup retrieves a document by name, returning an *UnknownPlaybookError with suggestions if it does not exist
+func (s *Snapshot) Lookup(name string) (parser.Document, error) {
+	return s.docs.Lookup(name)
+}
+
+// Resolve returns the documents a name refers to: the playbook itself, or the members of a bundle
+// when the name starts with BundlePrefix
+func (s *Snapshot) Resolve(name string) ([]parser.Document, error) {
+	if IsBundleName(name) {
+		return bundleDocuments(s.docs, s.bundles, name)
+	}
+
+	doc, err := s.docs.Lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return []parser.Document{doc}, nil
+}
+
+// ResolveAll resolves several names (see Resolve) in order. Repeated names, and
+// playbooks reached through more than one name, are returned once, where they first
+// appear. If any name is unknown, the error is an *UnknownPlaybooksError listing all of them.
+func (s *Snapshot) ResolveAll(names []string) ([]parser.Document, error) {
+	var docs []parser.Document
+	var unknown []*UnknownPlaybookError
+	seen := make(map[string]bool)    // playbooks already returned
+	missing := make(map[string]bool) // unknown names already reported
+	for _, name := range names {
+		resolved, err := s.Resolve(name)
+		if err != nil {
+			var notFound *UnknownPlaybookError
+			if !errors.As(err, &notFound) {
+				return nil, err
+			}
+			if !missing[name] {
+				missing[name] = true
+				unknown = append(unknown, notFound)
+			}
+			continue
+		}
+		for _, doc := range resolved {
+			if !seen[doc.Name] {
+				seen[doc.Name] = true
+				docs = append(docs, doc)
+			}
+		}
+	}
+
+	if len(unknown) > 0 {
+		return nil, &UnknownPlaybooksError{Errors: unknown}
+	}
+	return docs, nil
+}
+
+// Bundles returns the bundle names, without BundlePrefix, sorted alphabetically
+func (s *Snapshot) Bundles() []string {
+	return sortedBundleNames(s.bundles)
+}
+
+// BundleMembers returns the playbook names in a bundle, in order
+func (s *Snapshot) BundleMembers(name string) []string {
+	return append([]string(nil), s.bundles[bundleKey(name)]...)
+}
+
+// List returns all document names in Registry.List order
+func (s *Snapshot) List() []string {
+	return append([]string(nil), s.names...)
+}
+
+// GetAll returns all documents in List order
+func (s *Snapshot) GetAll() []parser.Document {
+	docs := make([]parser.Document, 0, len(s.names))
+	for _, name := range s.names {
+		docs = append(docs, s.docs[name])
+	}
+	return docs
+}
+
+// Listed returns the documents shown in listings, in List order (see Registry.Listed)
+func (s *Snapshot) Listed() []parser.Document {
+	return listed(s.GetAll())
+}
+
+// AtLeast returns the listed documents whose priority is min or higher, in List order
+func (s *Snapshot) AtLeast(min parser.Priority) []parser.Document {
+	return atLeast(s.Listed(), min)
+}
+
+// Replacement returns the playbook served in place of a deprecated one (see Registry.Replacement)
+func (s *Snapshot) Replacement(name string) (parser.Document, bool) {
+	return s.docs.Replacement(name)
+}
+
+// Count returns the number of documents in the snapshot
+func (s *Snapshot) Count() int {
+	return len(s.docs)
+}
+
+// Has checks if a document with the given name exists
+func (s *Snapshot) Has(name string) bool {
+	return s.docs.Has(name)
+}
@@ -0,0 +1,91 @@
+package registry
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/howto/internal/config"
+	"github.com/yourusername/howto/internal/parser"
+	"github.com/yourusername/howto/internal/semver"
+)
+
+// selectVersions keeps one version of each playbook in a library: the highest
+// version matching the project's pin (see config.ProjectConfig.Pin), or the
+// highest version when the name is not pinned. Unversioned docs count as 0.0.0
+// when the library also holds versioned docs of the same name; a lone
+// unversioned doc is kept whatever the pin, so project overrides still apply.
+func selectVersions(recorder *provenanceRecorder, docs []parser.Document, projectConfig *config.ProjectConfig) ([]parser.Document, []Diagnostic) {
+	groups := make(map[string][]int)
+	var order []string
+	for i, doc := range docs {
+		if _, ok := groups[doc.Name]; !ok {
+			order = append(order, doc.Name)
+		}
+		groups[doc.Name] = append(groups[doc.Name], i)
+	}
+
+	drop := make(map[int]bool)
+	var diagnostics []Diagnostic
+	for _, name := range order {
+		indexes := groups[name]
+		if len(indexes) == 1 && docs[indexes[0]].Version == "" {
+			continue
+		}
+
+		versions := make([]semver.Version, len(indexes))
+		for i, index := range indexes {
+			versions[i], _ = semver.Parse(docVersion(docs[index]))
+		}
+
+		rng, _ := semver.ParseRange("*")
+		pin := projectConfig.Pin(name)
+		if pin != "" {
+			if pinned, err := semver.ParseRange(pin); err == nil {
+				rng = pinned
+			} else {
+				pin = "" // Reported by validateRequire; fall back to the highest version
+			}
+		}
+
+		best := rng.Highest(versions)
+		for i, index := range indexes {
+			if i == best {
+				continue
+			}
+			drop[index] = true
+			reason := fmt.Sprintf("version %s does not satisfy %s@%s", versions[i], name, pin)
+			if best >= 0 {
+				reason = fmt.Sprintf("version %s; serving %s", versions[i], versions[best])
+			}
+			recorder.record(name, docs[index], OutcomeOtherVersion, reason)
+		}
+
+		if best < 0 {
+			available := make([]string, len(versions))
+			for i, version := range versions {
+				available[i] = version.String()
+			}
+			diagnostics = append(diagnostics, Diagnostic{
+				Severity: SeverityWarning,
+				Name:     name,
+				Message:  fmt.Sprintf("require entry %s@%s matches no version in the %s library (available: %s)", name, pin, docs[indexes[0]].Source, strings.Join(available, ", ")),
+			})
+		}
+	}
+
+	kept := make([]parser.Document, 0, len(docs)-len(drop))
+	for i, doc := range docs {
+		if !drop[i] {
+			kept = append(kept, doc)
+		}
+	}
+	return kept, diagnostics
+}
+
+// docVersion returns the version a doc is compared by; unversioned docs count as 0.0.0
+func docVersion(doc parser.Document) string {
+	if doc.Version == "" {
+		return "0"
+	}
+	return doc.Version
+}
@@ -0,0 +1,205 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/yourusername/howto/internal/parser"
+)
+
+// BM25 parameters.
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Field weights: a term in the name counts as much as several in the body.
+const (
+	weightName        = 3
+	weightTags        = 2
+	weightDescription = 2
+	weightBody        = 1
+)
+
+const maxSnippets = 3
+
+// Index ranks playbooks by relevance to a free-text query using BM25.
+// An Index is immutable once built and safe for concurrent use.
+type Index struct {
+	docs      []indexedDoc
+	docFreq   map[string]int
+	avgLength float64
+}
+
+type indexedDoc struct {
+	doc    parser.Document
+	terms  map[string]int // weighted term frequencies
+	length int
+	lines  []string
+}
+
+// Result is a single ranked match.
+type Result struct {
+	Name        string
+	Description string
+	Score       float64
+	Snippets    []Snippet
+}
+
+// Snippet is a body line that contains a query term.
+type Snippet struct {
+	Line int // 1-based line number within the playbook body
+	Text string
+}
+
+// NewIndex builds an index over the given documents.
+func NewIndex(docs []parser.Document) *Index {
+	idx := &Index{docFreq: make(map[string]int)}
+
+	totalLength := 0
+	for _, doc := range docs {
+		entry := indexedDoc{
+			doc:   doc,
+			terms: make(map[string]int),
+			lines: strings.Split(doc.Content, "\n"),
+		}
+
+		addTerms := func(text string, weight int) {
+			for _, term := range Tokenize(text) {
+				entry.terms[term] += weight
+				entry.length += weight
+			}
+		}
+		addTerms(doc.Name, weightName)
+		addTerms(strings.Join(doc.Tags, " "), weightTags)
+		addTerms(doc.Description, weightDescription)
+		addTerms(doc.Content, weightBody)
+
+		for term := range entry.terms {
+			idx.docFreq[term]++
+		}
+		totalLength += entry.length
+		idx.docs = append(idx.docs, entry)
+	}
+
+	if len(idx.docs) > 0 {
+		idx.avgLength = float64(totalLength) / float64(len(idx.docs))
+	}
+
+	return idx
+}
+
+// Search returns up to limit documents matching the query, best first.
+// A limit of zero or less returns every match.
+func (idx *Index) Search(query string, limit int) []Result {
+	if idx == nil {
+		return nil
+	}
+
+	terms := uniqueTerms(Tokenize(query))
+	if len(terms) == 0 {
+		return nil
+	}
+
+	n := float64(len(idx.docs))
+	var results []Result
+	for _, entry := range idx.docs {
+		score := 0.0
+		for _, term := range terms {
+			tf := float64(entry.terms[term])
+			if tf == 0 {
+				continue
+			}
+			df := float64(idx.docFreq[term])
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			norm := 1 - b + b*float64(entry.length)/idx.avgLength
+			score += idf * tf * (k1 + 1) / (tf + k1*norm)
+		}
+		if score == 0 {
+			continue
+		}
+
+		results = append(results, Result{
+			Name:        entry.doc.Name,
+			Description: entry.doc.Description,
+			Score:       score,
+			Snippets:    snippets(entry.lines, terms),
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Name < results[j].Name
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// snippets returns the first body lines that contain any of the terms.
+func snippets(lines []string, terms []string) []Snippet {
+	wanted := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		wanted[term] = true
+	}
+
+	var out []Snippet
+	for i, line := range lines {
+		for _, term := range Tokenize(line) {
+			if wanted[term] {
+				out = append(out, Snippet{Line: i + 1, Text: strings.TrimSpace(line)})
+				break
+			}
+		}
+		if len(out) == maxSnippets {
+			break
+		}
+	}
+	return out
+}
+
+// Tokenize lowercases text, splits it on anything that is not a letter or digit
+// and reduces simple plurals so "migrations" matches "migration".
+func Tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := make([]string, 0, len(fields))
+	for _, field := range fields {
+		terms = append(terms, stem(field))
+	}
+	return terms
+}
+
+func stem(term string) string {
+	switch {
+	case strings.HasSuffix(term, "sses"):
+		return term[:len(term)-2]
+	case len(term) > 4 && strings.HasSuffix(term, "ies"):
+		return term[:len(term)-3] + "y"
+	case len(term) > 3 && strings.HasSuffix(term, "s") && !strings.HasSuffix(term, "ss"):
+		return term[:len(term)-1]
+	default:
+		return term
+	}
+}
+
+func uniqueTerms(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	out := terms[:0]
+	for _, term := range terms {
+		if seen[term] {
+			continue
+		}
+		seen[term] = true
+		out = append(out, term)
+	}
+	return out
+}
@@ -0,0 +1,61 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/yourusername/howto/internal/parser"
+)
+
+func TestIndexSearchRanksByRelevance(t *testing.T) {
+	docs := []parser.Document{
+		{Name: "go-lang", Description: "Go conventions", Content: "Handle errors explicitly.\nKeep functions small."},
+		{Name: "db-migrations", Description: "How to write database migrations", Tags: []string{"database", "sql"}, Content: "Never edit an applied migration.\nRun migrations in a transaction."},
+		{Name: "deploy", Description: "Deployment procedure", Content: "Run database migrations before switching traffic."},
+	}
+
+	idx := NewIndex(docs)
+	results := idx.Search("database migrations", 0)
+
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d: %+v", len(results), results)
+	}
+	if results[0].Name != "db-migrations" {
+		t.Errorf("expected db-migrations first, got %s", results[0].Name)
+	}
+	if results[1].Name != "deploy" {
+		t.Errorf("expected deploy second, got %s", results[1].Name)
+	}
+
+	snippets := results[0].Snippets
+	if len(snippets) != 2 || snippets[0].Line != 1 || snippets[1].Text != "Run migrations in a transaction." {
+		t.Errorf("unexpected snippets: %+v", snippets)
+	}
+}
+
+func TestIndexSearchLimitAndEmptyQuery(t *testing.T) {
+	docs := []parser.Document{
+		{Name: "a", Description: "testing one", Content: "testing"},
+		{Name: "b", Description: "testing two", Content: "testing"},
+	}
+	idx := NewIndex(docs)
+
+	if results := idx.Search("testing", 1); len(results) != 1 {
+		t.Errorf("expected limit to apply, got %d results", len(results))
+	}
+	if results := idx.Search("  --- ", 0); len(results) != 0 {
+		t.Errorf("expected no results for empty query, got %d", len(results))
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize("Database-Migrations: policies & classes")
+	expected := []string{"database", "migration", "policy", "class"}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("token %d = %q, want %q", i, got[i], expected[i])
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package semver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a MAJOR.MINOR.PATCH version. Pre-release and build suffixes are not supported.
+type Version struct {
+	Major, Minor, Patch int
+}
+
+// Parse reads a version such as "2", "2.1" or "v2.1.3". Missing parts default to zero.
+func Parse(text string) (Version, error) {
+	version, _, err := parsePartial(text)
+	return version, err
+}
+
+// parsePartial also reports how many parts were written, which ranges need
+func parsePartial(text string) (Version, int, error) {
+	trimmed := strings.TrimPrefix(strings.TrimSpace(text), "v")
+	parts := strings.Split(trimmed, ".")
+	if trimmed == "" || len(parts) > 3 {
+		return Version{}, 0, fmt.Errorf("invalid version %q (expected MAJOR[.MINOR[.PATCH]])", text)
+	}
+
+	var numbers [3]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || strings.HasPrefix(part, "+") {
+			return Version{}, 0, fmt.Errorf("invalid version %q (expected MAJOR[.MINOR[.PATCH]])", text)
+		}
+		numbers[i] = n
+	}
+	return Version{Major: numbers[0], Minor: numbers[1], Patch: numbers[2]}, len(parts), nil
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// Compare returns -1, 0 or 1 depending on whether v is lower than, equal to or higher than other.
+func (v Version) Compare(other Version) int {
+	for _, diff := range []int{v.Major - other.Major, v.Minor - other.Minor, v.Patch - other.Patch} {
+		switch {
+		case diff < 0:
+			return -1
+		case diff > 0:
+			return 1
+		}
+	}
+	return 0
+}
+
+// bound is a single comparison such as ">= 2.0.0"
+type bound struct {
+	op      string
+	version Version
+}
+
+func (b bound) matches(v Version) bool {
+	cmp := v.Compare(b.version)
+	switch b.op {
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	default:
+		return cmp == 0
+	}
+}
+
+// Range is a set of versions, written like npm and Cargo ranges:
+//
+//	^2.1    >=2.1.0 <3.0.0 (compatible releases; ^0.2 allows only 0.2.x)
+//	~2.1    >=2.1.0 <2.2.0 (patch releases)
+//	2, 2.1  any 2.x.x or 2.1.x
+//	2.1.3   exactly 2.1.3
+//	>=2 <3  comparisons, separated by spaces or commas, must all hold
+//	*       any version
+type Range struct {
+	text   string
+	bounds []bound
+}
+
+// ParseRange reads a version range.
+func ParseRange(text string) (Range, error) {
+	r := Range{text: strings.TrimSpace(text)}
+	terms := strings.FieldsFunc(r.text, func(c rune) bool { return c == ' ' || c == ',' })
+	if len(terms) == 0 {
+		return Range{}, fmt.Errorf("empty version range")
+	}
+
+	for _, term := range terms {
+		bounds, err := parseTerm(term)
+		if err != nil {
+			return Range{}, fmt.Errorf("invalid version range %q: %w", text, err)
+		}
+		r.bounds = append(r.bounds, bounds...)
+	}
+	return r, nil
+}
+
+func parseTerm(term string) ([]bound, error) {
+	if term == "*" || term == "x" {
+		return nil, nil
+	}
+	for _, op := range []string{">=", "<=", ">", "<"} {
+		if strings.HasPrefix(term, op) {
+			version, err := Parse(term[len(op):])
+			if err != nil {
+				return nil, err
+			}
+			return []bound{{op: op, version: version}}, nil
+		}
+	}
+
+	operator := ""
+	if strings.HasPrefix(term, "^") || strings.HasPrefix(term, "~") || strings.HasPrefix(term, "=") {
+		operator, term = term[:1], term[1:]
+	}
+	low, parts, err := parsePartial(term)
+	if err != nil {
+		return nil, err
+	}
+
+	var high Version
+	switch {
+	case operator == "^" && low.Major > 0, parts == 1:
+		high = Version{Major: low.Major + 1}
+	case operator == "^" && low.Minor > 0, operator == "~", parts == 2:
+		high = Version{Major: low.Major, Minor: low.Minor + 1}
+	case operator == "^":
+		high = Version{Major: low.Major, Minor: low.Minor, Patch: low.Patch + 1}
+	default:
+		return []bound{{op: "=", version: low}}, nil
+	}
+	return []bound{{op: ">=", version: low}, {op: "<", version: high}}, nil
+}
+
+// Matches reports whether v is in the range.
+func (r Range) Matches(v Version) bool {
+	for _, b := range r.bounds {
+		if !b.matches(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r Range) String() string {
+	return r.text
+}
+
+// Highest returns the index of the highest version in versions that r matches, or -1 if none does.
+func (r Range) Highest(versions []Version) int {
+	best := -1
+	for i, v := range versions {
+		if r.Matches(v) && (best == -1 || v.Compare(versions[best]) > 0) {
+			best = i
+		}
+	}
+	return best
+}
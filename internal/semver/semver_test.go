@@ -0,0 +1,80 @@
+package semver
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := map[string]string{
+		"2":      "2.0.0",
+		"2.1":    "2.1.0",
+		"v2.1.3": "2.1.3",
+	}
+	for input, expected := range tests {
+		version, err := Parse(input)
+		if err != nil {
+			t.Errorf("Parse(%q) returned error: %v", input, err)
+			continue
+		}
+		if version.String() != expected {
+			t.Errorf("Parse(%q) = %s, want %s", input, version, expected)
+		}
+	}
+
+	for _, input := range []string{"", "two", "1.2.3.4", "1.-2", "1.2.3-beta"} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Parse(%q) expected an error", input)
+		}
+	}
+}
+
+func TestRangeMatches(t *testing.T) {
+	tests := []struct {
+		rng     string
+		matches []string
+		misses  []string
+	}{
+		{"^2", []string{"2.0.0", "2.9.1"}, []string{"1.9.9", "3.0.0"}},
+		{"^2.1", []string{"2.1.0", "2.5.0"}, []string{"2.0.9", "3.0.0"}},
+		{"^0.2", []string{"0.2.0", "0.2.7"}, []string{"0.3.0"}},
+		{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+		{"~2.1", []string{"2.1.0", "2.1.9"}, []string{"2.2.0"}},
+		{"2", []string{"2.0.0", "2.3.0"}, []string{"3.0.0"}},
+		{"2.1.3", []string{"2.1.3"}, []string{"2.1.4"}},
+		{">=1.5, <2", []string{"1.5.0", "1.9.0"}, []string{"1.4.0", "2.0.0"}},
+		{"*", []string{"0.0.1", "9.0.0"}, nil},
+	}
+
+	for _, tt := range tests {
+		rng, err := ParseRange(tt.rng)
+		if err != nil {
+			t.Fatalf("ParseRange(%q) returned error: %v", tt.rng, err)
+		}
+		for _, text := range tt.matches {
+			if v, _ := Parse(text); !rng.Matches(v) {
+				t.Errorf("expected %q to match %s", tt.rng, text)
+			}
+		}
+		for _, text := range tt.misses {
+			if v, _ := Parse(text); rng.Matches(v) {
+				t.Errorf("expected %q not to match %s", tt.rng, text)
+			}
+		}
+	}
+
+	if _, err := ParseRange("^two"); err == nil {
+		t.Error("expected an error for an invalid range")
+	}
+}
+
+func TestRangeHighest(t *testing.T) {
+	versions := []Version{{Major: 1}, {Major: 2, Minor: 3}, {Major: 2, Minor: 1}, {Major: 3}}
+
+	rng, _ := ParseRange("^2")
+	if best := rng.Highest(versions); best != 1 {
+		t.Errorf("expected 2.3.0 to be the highest match, got index %d", best)
+	}
+
+	rng, _ = ParseRange("^4")
+	if best := rng.Highest(versions); best != -1 {
+		t.Errorf("expected no match, got index %d", best)
+	}
+}
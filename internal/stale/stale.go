@@ -0,0 +1,104 @@
+package stale
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/howto/internal/parser"
+	"github.com/yourusername/howto/internal/registry"
+)
+
+// Formats accepted by Render.
+var Formats = []string{"text", "json"}
+
+// Entry is a served playbook whose review_by date has passed.
+type Entry struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Source      string   `json:"source"`
+	Path        string   `json:"path"`
+	Owners      []string `json:"owners"`
+	ReviewBy    string   `json:"review_by"`
+	DaysOverdue int      `json:"days_overdue"`
+}
+
+// Report lists the overdue playbooks of a snapshot as of a given day.
+type Report struct {
+	AsOf     string  `json:"as_of"`
+	Revision string  `json:"revision"`
+	Stale    []Entry `json:"stale"`
+}
+
+// Build collects every served playbook whose review_by date is before today,
+// most overdue first. Playbooks without a review_by date are never stale.
+func Build(snapshot *registry.Snapshot, today time.Time) Report {
+	day := date(today)
+	report := Report{
+		AsOf:     day.Format(parser.ReviewByLayout),
+		Revision: snapshot.Revision(),
+		Stale:    []Entry{},
+	}
+
+	for _, doc := range snapshot.GetAll() {
+		if doc.ReviewBy.IsZero() || !doc.ReviewBy.Before(day) {
+			continue
+		}
+		report.Stale = append(report.Stale, Entry{
+			Name:        doc.Name,
+			Description: doc.Description,
+			Source:      doc.Source.String(),
+			Path:        doc.FilePath,
+			Owners:      append([]string{}, doc.Owners...),
+			ReviewBy:    doc.ReviewBy.Format(parser.ReviewByLayout),
+			DaysOverdue: int(day.Sub(date(doc.ReviewBy)).Hours() / 24),
+		})
+	}
+
+	sort.SliceStable(report.Stale, func(i, j int) bool {
+		return report.Stale[i].DaysOverdue > report.Stale[j].DaysOverdue
+	})
+	return report
+}
+
+// date drops the time of day so comparisons are by calendar date
+func date(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Render formats the report as text or json.
+func Render(report Report, format string) (string, error) {
+	switch format {
+	case "text":
+		return report.Text(), nil
+	case "json":
+		content, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to encode stale report: %w", err)
+		}
+		return string(content) + "\n", nil
+	default:
+		return "", fmt.Errorf("unknown stale format %q (expected %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// Text renders the report for people, one playbook per line.
+func (r Report) Text() string {
+	if len(r.Stale) == 0 {
+		return fmt.Sprintf("No playbooks are overdue for review as of %s.\n", r.AsOf)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Playbooks overdue for review as of %s:\n", r.AsOf)
+	for _, entry := range r.Stale {
+		owners := "no owners"
+		if len(entry.Owners) > 0 {
+			owners = strings.Join(entry.Owners, ", ")
+		}
+		fmt.Fprintf(&b, "  %s: review by %s (%d day(s) overdue), %s\n", entry.Name, entry.ReviewBy, entry.DaysOverdue, owners)
+		fmt.Fprintf(&b, "    %s\n", entry.Path)
+	}
+	return b.String()
+}
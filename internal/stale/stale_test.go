@@ -0,0 +1,66 @@
+package stale
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yourusername/howto/internal/parser"
+	"github.com/yourusername/howto/internal/registry"
+)
+
+func testSnapshot() *registry.Snapshot {
+	day := func(value string) time.Time {
+		t, _ := time.Parse(parser.ReviewByLayout, value)
+		return t
+	}
+	return registry.NewSnapshot(registry.Registry{
+		"deploy":  {Name: "deploy", Description: "Deploy", FilePath: "/global/deploy.md", Owners: []string{"@ops"}, ReviewBy: day("2024-03-01")},
+		"go-lang": {Name: "go-lang", Description: "Go", FilePath: "/global/go-lang.md", ReviewBy: day("2025-06-20")},
+		"commits": {Name: "commits", Description: "Commits", FilePath: "/global/commits.md", ReviewBy: day("2025-07-01")},
+		"testing": {Name: "testing", Description: "Testing", FilePath: "/global/testing.md"},
+	})
+}
+
+func TestBuild(t *testing.T) {
+	report := Build(testSnapshot(), time.Date(2025, 7, 1, 15, 0, 0, 0, time.UTC))
+
+	if report.AsOf != "2025-07-01" || len(report.Stale) != 2 {
+		t.Fatalf("expected two overdue playbooks as of 2025-07-01, got %+v", report)
+	}
+	if report.Stale[0].Name != "deploy" || report.Stale[0].DaysOverdue != 487 {
+		t.Errorf("expected deploy to be the most overdue, got %+v", report.Stale[0])
+	}
+	if report.Stale[1].Name != "go-lang" || report.Stale[1].DaysOverdue != 11 {
+		t.Errorf("expected go-lang 11 days overdue, got %+v", report.Stale[1])
+	}
+}
+
+func TestRender(t *testing.T) {
+	report := Build(testSnapshot(), time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC))
+
+	text, err := Render(report, "text")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(text, "deploy: review by 2024-03-01 (487 day(s) overdue), @ops") || !strings.Contains(text, "go-lang: review by 2025-06-20 (11 day(s) overdue), no owners") {
+		t.Errorf("unexpected text report: %q", text)
+	}
+
+	content, err := Render(report, "json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal([]byte(content), &decoded); err != nil {
+		t.Fatalf("expected valid JSON, got %v", err)
+	}
+	if decoded.Revision == "" || len(decoded.Stale) != 2 || decoded.Stale[1].Owners == nil {
+		t.Errorf("unexpected JSON report: %s", content)
+	}
+
+	if _, err := Render(report, "yaml"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
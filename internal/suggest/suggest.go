@@ -0,0 +1,105 @@
+package suggest
+
+import (
+	"sort"
+	"strings"
+)
+
+// Closest returns up to limit candidates that look like likely corrections for name.
+// Candidates are ranked by edit distance; those sharing a prefix with name rank first on ties.
+func Closest(name string, candidates []string, limit int) []string {
+	query := strings.ToLower(strings.TrimSpace(name))
+	if query == "" || limit <= 0 {
+		return nil
+	}
+
+	type match struct {
+		name     string
+		distance int
+		prefix   bool
+	}
+
+	seen := make(map[string]bool, len(candidates))
+	var matches []match
+	for _, candidate := range candidates {
+		if seen[candidate] || candidate == name {
+			continue
+		}
+		seen[candidate] = true
+
+		lower := strings.ToLower(candidate)
+		prefix := strings.HasPrefix(lower, query) || strings.HasPrefix(query, lower)
+		distance := Distance(query, lower)
+
+		if !prefix && distance > maxDistance(query) {
+			continue
+		}
+		matches = append(matches, match{name: candidate, distance: distance, prefix: prefix})
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		if matches[i].prefix != matches[j].prefix {
+			return matches[i].prefix
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	out := make([]string, len(matches))
+	for i, m := range matches {
+		out[i] = m.name
+	}
+	return out
+}
+
+// Distance returns the Levenshtein edit distance between a and b.
+func Distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// maxDistance scales the accepted number of edits with the length of the query.
+func maxDistance(query string) int {
+	n := len([]rune(query)) / 3
+	if n < 2 {
+		return 2
+	}
+	return n
+}
+
+// Phrase renders suggestions as a "did you mean" clause, or an empty string when there are none.
+func Phrase(suggestions []string) string {
+	if len(suggestions) == 0 {
+		return ""
+	}
+
+	quoted := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		quoted[i] = `"` + s + `"`
+	}
+	return "did you mean " + strings.Join(quoted, " or ") + "?"
+}
@@ -0,0 +1,59 @@
+package suggest
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"optinal-rule", "optional-rule", 1},
+		{"kitten", "sitting", 3},
+		{"go-lang", "", 7},
+	}
+
+	for _, tt := range tests {
+		if got := Distance(tt.a, tt.b); got != tt.expected {
+			t.Errorf("Distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
+
+func TestClosest(t *testing.T) {
+	candidates := []string{"optional-rule", "go-lang", "rust-lang", "commits"}
+
+	tests := []struct {
+		name     string
+		expected []string
+	}{
+		{"optinal-rule", []string{"optional-rule"}},
+		{"golang", []string{"go-lang"}},
+		{"commit", []string{"commits"}},
+		{"database-migrations", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Closest(tt.name, candidates, 3)
+			if len(got) == 0 && len(tt.expected) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Closest(%q) = %v, want %v", tt.name, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPhrase(t *testing.T) {
+	if got := Phrase(nil); got != "" {
+		t.Errorf("expected empty phrase, got %q", got)
+	}
+	if got := Phrase([]string{"go-lang", "rust-lang"}); got != `did you mean "go-lang" or "rust-lang"?` {
+		t.Errorf("unexpected phrase %q", got)
+	}
+}
@@ -0,0 +1,93 @@
+// Package tokens estimates how many tokens a language model spends on a text.
+//
+// The estimate is an offline approximation of the byte-pair encodings used by
+// current models, so it needs no vocabulary file and no network access. It is
+// meant for budgets and listings, not billing: expect it to be within a few
+// tens of percent of a real tokenizer for English Markdown.
+package tokens
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// wordBytes is roughly how many bytes of a word a BPE vocabulary covers per token.
+	// Common English words are a single token; longer and rarer ones split.
+	wordBytes = 6
+	// digitsPerToken matches tokenizers that split numbers into groups of three digits.
+	digitsPerToken = 3
+	// punctuationPerToken merges runs such as "```", "---" or "**" into fewer tokens.
+	punctuationPerToken = 2
+)
+
+// Estimate returns the approximate number of tokens in text
+func Estimate(text string) int {
+	count := 0
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case r == ' ':
+			// A single space is folded into the token that follows it
+			i += size
+			if next := runLength(text[i:], func(r rune) bool { return r == ' ' }); next > 0 {
+				count++
+				i += next
+			}
+		case unicode.IsSpace(r):
+			count++
+			i += runLength(text[i:], unicode.IsSpace)
+		case isIdeograph(r):
+			count++
+			i += size
+		case isWordRune(r):
+			n := runLength(text[i:], isWordRune)
+			count += ceilDiv(n, wordBytes)
+			i += n
+		case unicode.IsDigit(r):
+			n := runLength(text[i:], unicode.IsDigit)
+			count += ceilDiv(utf8.RuneCountInString(text[i:i+n]), digitsPerToken)
+			i += n
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			if r >= utf8.RuneSelf {
+				// Emoji and other symbols usually take a token or more each
+				count++
+				i += size
+				continue
+			}
+			n := runLength(text[i:], func(next rune) bool { return next == r })
+			count += ceilDiv(n, punctuationPerToken)
+			i += n
+		default:
+			count++
+			i += size
+		}
+	}
+	return count
+}
+
+// runLength returns the number of leading bytes of text whose runes satisfy match
+func runLength(text string, match func(rune) bool) int {
+	for i, r := range text {
+		if !match(r) {
+			return i
+		}
+	}
+	return len(text)
+}
+
+// isWordRune reports whether r continues a word. Non-ASCII letters count by
+// their UTF-8 length, which tracks how BPE vocabularies split other scripts.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) && !isIdeograph(r) || unicode.IsMark(r) || r == '_'
+}
+
+// isIdeograph reports whether r belongs to a script written without spaces,
+// where tokenizers spend about one token per character
+func isIdeograph(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul, unicode.Thai)
+}
+
+func ceilDiv(n, d int) int {
+	return (n + d - 1) / d
+}
@@ -0,0 +1,40 @@
+package tokens
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected int
+	}{
+		{"", 0},
+		{"hello world", 2},
+		{"internationalization", 4},
+		{"2025-07-01", 6},
+		{"```go", 3},
+		{"line\n\nnext", 3},
+		{"a  b", 3},
+		{"日本語", 3},
+		{"café au lait", 3},
+	}
+
+	for _, tt := range tests {
+		if got := Estimate(tt.text); got != tt.expected {
+			t.Errorf("Estimate(%q) = %d, want %d", tt.text, got, tt.expected)
+		}
+	}
+}
+
+func TestEstimateGrowsWithText(t *testing.T) {
+	sentence := "Run the migrations before deploying, then check the logs for errors.\n"
+	one, ten := Estimate(sentence), Estimate(strings.Repeat(sentence, 10))
+	if one < 10 || one > 20 {
+		t.Errorf("expected roughly one token per word, got %d for %q", one, sentence)
+	}
+	if ten != 10*one {
+		t.Errorf("expected the estimate to be additive across lines, got %d for ten copies of %d", ten, one)
+	}
+}
@@ -0,0 +1,203 @@
+package xref
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/yourusername/howto/internal/parser"
+)
+
+// Style selects how links are rewritten for an output target.
+type Style int
+
+const (
+	StyleCLI  Style = iota // Command hints, e.g. go-lang (`howto go-lang`)
+	StyleMCP               // Markdown links to howto:// resource URIs
+	StyleHTML              // HTML links to #name and #name-section anchors on the same page
+)
+
+// URIScheme prefixes the resource URIs used in MCP output.
+const URIScheme = "howto://playbook/"
+
+// PlaybookName returns the playbook a resource URI points to, ignoring any section fragment.
+func PlaybookName(uri string) (string, bool) {
+	name, ok := strings.CutPrefix(uri, URIScheme)
+	if !ok {
+		return "", false
+	}
+	name, _, _ = strings.Cut(name, "#")
+	return name, name != ""
+}
+
+// Link is a [[target]] or [[target#section]] reference in a playbook body.
+type Link struct {
+	Target  string // Playbook name
+	Section string // Heading text, if the link points into a section
+	Line    int    // 1-based line within the body
+	Raw     string // The link as written, including brackets
+}
+
+var linkPattern = regexp.MustCompile(`\[\[([^\[\]#|]+)(?:#([^\[\]|]+))?\]\]`)
+
+// Find returns the links in a playbook body, skipping fenced code blocks.
+func Find(content string) []Link {
+	var links []Link
+	forEachLine(content, func(lineNumber int, line string, inCode bool) string {
+		if inCode {
+			return line
+		}
+		for _, match := range linkPattern.FindAllStringSubmatch(line, -1) {
+			links = append(links, newLink(match, lineNumber))
+		}
+		return line
+	})
+	return links
+}
+
+// Rewrite replaces every link in content with its rendering for style.
+// Fenced code blocks are left untouched.
+func Rewrite(content string, style Style) string {
+	return forEachLine(content, func(lineNumber int, line string, inCode bool) string {
+		if inCode {
+			return line
+		}
+		return linkPattern.ReplaceAllStringFunc(line, func(raw string) string {
+			return Render(newLink(linkPattern.FindStringSubmatch(raw), lineNumber), style)
+		})
+	})
+}
+
+// RewriteDocuments returns copies of docs with their links rewritten for style.
+func RewriteDocuments(docs []parser.Document, style Style) []parser.Document {
+	out := make([]parser.Document, len(docs))
+	for i, doc := range docs {
+		doc.Content = Rewrite(doc.Content, style)
+		out[i] = doc
+	}
+	return out
+}
+
+// Render formats a single link for style.
+func Render(link Link, style Style) string {
+	label := link.Target
+	fragment := ""
+	if link.Section != "" {
+		label = fmt.Sprintf("%s § %s", link.Target, link.Section)
+		fragment = Slug(link.Section)
+	}
+
+	switch style {
+	case StyleMCP:
+		if fragment != "" {
+			fragment = "#" + fragment
+		}
+		return fmt.Sprintf("[%s](%s%s%s)", label, URIScheme, link.Target, fragment)
+	case StyleHTML:
+		anchor := link.Target
+		if fragment != "" {
+			anchor += "-" + fragment
+		}
+		return fmt.Sprintf(`<a href="#%s">%s</a>`, html.EscapeString(anchor), html.EscapeString(label))
+	default:
+		if link.Section != "" {
+			return fmt.Sprintf("%s, section %q (`howto %s`)", link.Target, link.Section, link.Target)
+		}
+		return fmt.Sprintf("%s (`howto %s`)", link.Target, link.Target)
+	}
+}
+
+// Headings returns the slugs of every Markdown heading in content, outside fenced code blocks.
+func Headings(content string) map[string]bool {
+	headings := make(map[string]bool)
+	forEachLine(content, func(_ int, line string, inCode bool) string {
+		trimmed := strings.TrimSpace(line)
+		if !inCode && strings.HasPrefix(trimmed, "#") {
+			text := strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+			if text != "" {
+				headings[Slug(text)] = true
+			}
+		}
+		return line
+	})
+	return headings
+}
+
+// Section is a run of playbook content starting at a Markdown heading. The
+// first section has no heading when the content starts with text.
+type Section struct {
+	Heading string // Heading text without the leading #s
+	Text    string // The heading line and everything up to the next heading
+}
+
+// Sections splits content at every Markdown heading outside fenced code
+// blocks. Joining the Text of every section reproduces content.
+func Sections(content string) []Section {
+	var sections []Section
+	var current []string
+	heading := ""
+	flush := func() {
+		if len(current) > 0 {
+			sections = append(sections, Section{Heading: heading, Text: strings.Join(current, "\n")})
+		}
+	}
+	forEachLine(content, func(_ int, line string, inCode bool) string {
+		trimmed := strings.TrimSpace(line)
+		if !inCode && strings.HasPrefix(trimmed, "#") {
+			if text := strings.TrimSpace(strings.TrimLeft(trimmed, "#")); text != "" {
+				flush()
+				heading, current = text, nil
+			}
+		}
+		current = append(current, line)
+		return line
+	})
+	flush()
+	for i := range sections[:max(len(sections)-1, 0)] {
+		sections[i].Text += "\n"
+	}
+	return sections
+}
+
+// Slug turns heading text into an anchor the way Markdown renderers commonly do:
+// lowercase, spaces become hyphens and punctuation is dropped.
+func Slug(text string) string {
+	var builder strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(text)) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			builder.WriteRune(r)
+		case unicode.IsSpace(r):
+			builder.WriteRune('-')
+		}
+	}
+	return builder.String()
+}
+
+func newLink(match []string, line int) Link {
+	return Link{
+		Target:  strings.TrimSpace(match[1]),
+		Section: strings.TrimSpace(match[2]),
+		Line:    line,
+		Raw:     match[0],
+	}
+}
+
+// forEachLine calls fn for every line of content, reporting whether the line is
+// inside a fenced code block, and joins the lines fn returns.
+func forEachLine(content string, fn func(lineNumber int, line string, inCode bool) string) string {
+	lines := strings.Split(content, "\n")
+	inCode := false
+	for i, line := range lines {
+		fence := strings.HasPrefix(strings.TrimSpace(line), "```") || strings.HasPrefix(strings.TrimSpace(line), "~~~")
+		if fence {
+			lines[i] = fn(i+1, line, true)
+			inCode = !inCode
+			continue
+		}
+		lines[i] = fn(i+1, line, inCode)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,99 @@
+package xref
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	content := "See [[go-lang]] first.\n" +
+		"```\n" +
+		"[[not-a-link]]\n" +
+		"```\n" +
+		"Then [[commits#Message Format]] and [[db]]."
+
+	expected := []Link{
+		{Target: "go-lang", Line: 1, Raw: "[[go-lang]]"},
+		{Target: "commits", Section: "Message Format", Line: 5, Raw: "[[commits#Message Format]]"},
+		{Target: "db", Line: 5, Raw: "[[db]]"},
+	}
+	if got := Find(content); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Find() = %+v, expected %+v", got, expected)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	content := "Read [[commits#Message Format]].\n```\n[[kept]]\n```"
+
+	tests := []struct {
+		style    Style
+		expected string
+	}{
+		{StyleCLI, "Read commits, section \"Message Format\" (`howto commits`).\n```\n[[kept]]\n```"},
+		{StyleMCP, "Read [commits § Message Format](howto://playbook/commits#message-format).\n```\n[[kept]]\n```"},
+		{StyleHTML, "Read <a href=\"#commits-message-format\">commits § Message Format</a>.\n```\n[[kept]]\n```"},
+	}
+	for _, tt := range tests {
+		if got := Rewrite(content, tt.style); got != tt.expected {
+			t.Errorf("Rewrite(style %d) = %q, expected %q", tt.style, got, tt.expected)
+		}
+	}
+
+	if got := Rewrite("Use [[go-lang]].", StyleCLI); got != "Use go-lang (`howto go-lang`)." {
+		t.Errorf("unexpected CLI rewrite: %q", got)
+	}
+	if got := Rewrite("Use [[go-lang]].", StyleMCP); got != "Use [go-lang](howto://playbook/go-lang)." {
+		t.Errorf("unexpected MCP rewrite: %q", got)
+	}
+	if got := Rewrite("Use [[a<b]].", StyleHTML); got != `Use <a href="#a&lt;b">a&lt;b</a>.` {
+		t.Errorf("expected HTML rewrite to escape the target, got %q", got)
+	}
+}
+
+func TestPlaybookName(t *testing.T) {
+	tests := []struct {
+		uri  string
+		name string
+		ok   bool
+	}{
+		{"howto://playbook/commits", "commits", true},
+		{"howto://playbook/commits#message-format", "commits", true},
+		{"howto://playbook/", "", false},
+		{"file:///commits.md", "", false},
+	}
+	for _, tt := range tests {
+		if name, ok := PlaybookName(tt.uri); name != tt.name || ok != tt.ok {
+			t.Errorf("PlaybookName(%q) = %q, %v; expected %q, %v", tt.uri, name, ok, tt.name, tt.ok)
+		}
+	}
+}
+
+func TestHeadingsAndSlug(t *testing.T) {
+	headings := Headings("# Go Rules\n\n## Error Handling!\n```\n# not a heading\n```\n")
+	expected := map[string]bool{"go-rules": true, "error-handling": true}
+	if !reflect.DeepEqual(headings, expected) {
+		t.Errorf("Headings() = %v, expected %v", headings, expected)
+	}
+}
+
+func TestSections(t *testing.T) {
+	content := "Intro line.\n\n## Setup\nInstall it.\n```sh\n# not a heading\n```\n## Rollback\nUndo it."
+
+	expected := []Section{
+		{Heading: "", Text: "Intro line.\n\n"},
+		{Heading: "Setup", Text: "## Setup\nInstall it.\n```sh\n# not a heading\n```\n"},
+		{Heading: "Rollback", Text: "## Rollback\nUndo it."},
+	}
+	sections := Sections(content)
+	if !reflect.DeepEqual(sections, expected) {
+		t.Fatalf("Sections() = %+v, expected %+v", sections, expected)
+	}
+
+	joined := ""
+	for _, section := range sections {
+		joined += section.Text
+	}
+	if joined != content {
+		t.Errorf("expected sections to reproduce the content, got %q", joined)
+	}
+}
@@ -1,63 +1,311 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/yourusername/howto/internal/app"
+	"github.com/yourusername/howto/internal/graph"
 	"github.com/yourusername/howto/internal/output"
+	"github.com/yourusername/howto/internal/registry"
+	"github.com/yourusername/howto/internal/stale"
 )
 
 var version = "dev"
 
+// searchLimit caps the number of results printed by `howto search`.
+const searchLimit = 10
+
+// subcommands are the command names howto reserves. A playbook with one of these
+// names is fetched with `howto -- <name>`.
+var subcommands = []string{"search", "explain", "graph", "stale", "trust"}
+
+// commandFormats lists the --format values each command accepts; the first is the default.
+// The empty command lists or fetches playbooks.
+var commandFormats = map[string][]string{
+	"":      {"text", "json", "xml"},
+	"graph": graph.Formats,
+	"stale": stale.Formats,
+}
+
 func main() {
 	if err := run(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		var reported reportedError
+		if !errors.As(err, &reported) {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
 		os.Exit(1)
 	}
 }
 
-func run() error {
-	// Parse playbook arguments
-	args := os.Args[1:] // Skip program name
+// reportedError is an error that was already written to stdout as JSON or XML
+type reportedError struct {
+	error
+}
+
+// cliOptions holds the flags accepted by the howto command.
+type cliOptions struct {
+	showVersion bool
+	profile     string
+	strict      bool
+	format      string
+	maxTokens   int
+	paths       app.PathOverrides
 
-	if len(args) > 1 {
-		return fmt.Errorf("too many arguments (expected 0 or 1, got %d)", len(args))
+	// namesFrom is the number of positional arguments before `--`; those after it
+	// are always playbook names. It is -1 when there is no `--`.
+	namesFrom int
+}
+
+func run() error {
+	opts, args, err := parseArgs(os.Args[1:]) // Skip program name
+	if err == nil {
+		err = runCommand(opts, args)
 	}
+	if err != nil {
+		switch opts.format {
+		case "json":
+			if writeErr := output.WriteJSON(os.Stdout, output.NewError(err)); writeErr == nil {
+				return reportedError{err}
+			}
+		case "xml":
+			fmt.Fprint(os.Stdout, output.FormatXMLError(output.NewError(err)))
+			return reportedError{err}
+		}
+	}
+	return err
+}
 
-	if len(args) == 1 && args[0] == "--version" {
+func runCommand(opts cliOptions, args []string) error {
+	if opts.showVersion {
 		fmt.Fprintln(os.Stdout, version)
 		return nil
 	}
 
-	// Resolve paths
-	globalPath, err := app.GlobalConfigDir()
-	if err != nil {
-		return fmt.Errorf("failed to get global config path: %w", err)
+	command := ""
+	if len(args) > 0 && opts.namesFrom != 0 && slices.Contains(subcommands, args[0]) {
+		command = args[0]
+	}
+	if opts.format != "" {
+		formats, ok := commandFormats[command]
+		if !ok {
+			return fmt.Errorf("--format is not supported by %s", command)
+		}
+		if !slices.Contains(formats, opts.format) {
+			return fmt.Errorf("unknown format %q (expected %s)", opts.format, strings.Join(formats, ", "))
+		}
 	}
 
-	projectPath, err := app.ProjectConfigDir()
+	switch {
+	case opts.maxTokens < 0:
+		return fmt.Errorf("--max-tokens must be positive")
+	case opts.maxTokens > 0 && (command != "" || len(args) == 0):
+		return fmt.Errorf("--max-tokens only applies when fetching playbooks")
+	}
+
+	paths, err := app.ResolvePaths(opts.paths)
 	if err != nil {
-		return fmt.Errorf("failed to get project path: %w", err)
+		return err
+	}
+
+	if command == "trust" {
+		return runTrust(os.Stdout, paths, args[1:])
+	}
+
+	switch {
+	case command == "search" && len(args) < 2:
+		return fmt.Errorf("search requires a query")
+	case command == "explain" && len(args) != 2:
+		return fmt.Errorf("explain requires exactly one playbook name")
+	case command == "graph" && len(args) > 1:
+		return fmt.Errorf("graph does not accept arguments")
+	case command == "stale" && len(args) > 1:
+		return fmt.Errorf("stale does not accept arguments")
 	}
 
 	// Build registry
-	reg, err := app.LoadRegistry(globalPath, projectPath)
+	catalog, err := app.LoadRegistry(paths, app.LoadOptions{Profile: opts.profile, Strict: opts.strict})
 	if err != nil {
 		return err
 	}
 
+	problems := 0
+	catalog.Diagnostics = append(catalog.Diagnostics, subcommandCollisions(catalog.Registry)...)
+	for _, diagnostic := range catalog.Diagnostics {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", severityLabel(diagnostic.Severity), diagnostic)
+		if diagnostic.Severity.Problem() {
+			problems++
+		}
+	}
+	if opts.strict && problems > 0 {
+		return fmt.Errorf("strict mode: %d problem(s) found in the playbook libraries", problems)
+	}
+
+	reg := catalog.Registry
+	switch command {
+	case "search":
+		query := strings.Join(args[1:], " ")
+		output.PrintSearchResults(os.Stdout, query, catalog.Index.Search(query, searchLimit))
+		return nil
+	case "explain":
+		return output.PrintExplanation(os.Stdout, catalog.Provenance, args[1])
+	case "graph":
+		return runGraph(os.Stdout, catalog, opts.format)
+	case "stale":
+		return runStale(os.Stdout, catalog, opts.format, time.Now())
+	}
+
+	switch opts.format {
+	case "json":
+		if len(args) == 0 {
+			return output.WriteJSON(os.Stdout, output.NewCatalogue(reg, catalog.Rules.CLI, string(catalog.Trust), catalog.Diagnostics))
+		}
+		return output.PrintJSONPlaybooks(os.Stdout, reg, args, string(catalog.Trust), opts.maxTokens)
+	case "xml":
+		if len(args) == 0 {
+			fmt.Fprint(os.Stdout, output.FormatXMLCatalogue(output.NewCatalogue(reg, catalog.Rules.CLI, string(catalog.Trust), catalog.Diagnostics)))
+			return nil
+		}
+		return output.PrintXMLPlaybooks(os.Stdout, reg, args, string(catalog.Trust), opts.maxTokens)
+	}
+
 	if len(args) == 0 {
 		// No arguments - print help
-		output.PrintHelp(os.Stdout, reg)
+		output.PrintHelp(os.Stdout, reg, catalog.Rules.CLI)
 		return nil
 	}
 
-	// Print specific playbook
-	playbookName := args[0]
-	if err := output.PrintPlaybook(os.Stdout, reg, playbookName); err != nil {
+	// Print the requested playbooks
+	return output.PrintPlaybooks(os.Stdout, reg, args, opts.maxTokens)
+}
+
+// subcommandCollisions reports served playbooks that `howto <name>` cannot fetch
+// because a subcommand has the same name
+func subcommandCollisions(reg *registry.Snapshot) []registry.Diagnostic {
+	var diagnostics []registry.Diagnostic
+	for _, name := range subcommands {
+		doc, ok := reg.Get(name)
+		if !ok {
+			continue
+		}
+		diagnostics = append(diagnostics, registry.Diagnostic{
+			Severity: registry.SeverityWarning,
+			Name:     name,
+			Path:     doc.FilePath,
+			Message:  fmt.Sprintf("playbook %q shares its name with the `howto %s` command; fetch it with `howto -- %s` or rename it", name, name, name),
+		})
+	}
+	return diagnostics
+}
+
+// severityLabel capitalises a diagnostic severity for stderr, e.g. "Warning"
+func severityLabel(severity registry.Severity) string {
+	label := string(severity)
+	if label == "" {
+		return "Warning"
+	}
+	return strings.ToUpper(label[:1]) + label[1:]
+}
+
+// runGraph prints how the global and project layers combine, defaulting to Graphviz dot
+func runGraph(w io.Writer, catalog *app.Catalog, format string) error {
+	if format == "" {
+		format = "dot"
+	}
+
+	g := graph.Build(graph.Input{
+		Snapshot:   catalog.Registry,
+		Provenance: catalog.Provenance,
+		Require:    catalog.Require,
+	})
+	text, err := graph.Render(g, format)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(w, text)
+	return nil
+}
+
+// runStale lists playbooks whose review_by date has passed, defaulting to text
+func runStale(w io.Writer, catalog *app.Catalog, format string, today time.Time) error {
+	if format == "" {
+		format = "text"
+	}
+
+	text, err := stale.Render(stale.Build(catalog.Registry, today), format)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(w, text)
+	return nil
+}
+
+// runTrust approves the current contents of the project library.
+func runTrust(w io.Writer, paths app.Paths, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("trust does not accept arguments")
+	}
+
+	store, err := app.LoadTrustStore(paths.StateDir)
+	if err != nil {
 		return err
 	}
 
+	files, err := store.Trust(paths.ProjectDir)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Trusted project library %s:\n", paths.ProjectDir)
+	for _, file := range files {
+		fmt.Fprintf(w, "  %s\n", file)
+	}
+	fmt.Fprintln(w, "Run `howto trust` again after reviewing any future changes.")
 	return nil
 }
+
+// parseArgs parses flags and positional arguments. Flags may appear before or after playbook names.
+// Everything after `--` is a playbook name, even when it looks like a flag or a subcommand.
+func parseArgs(argv []string) (cliOptions, []string, error) {
+	opts := cliOptions{namesFrom: -1}
+
+	var names []string
+	if i := slices.Index(argv, "--"); i >= 0 {
+		argv, names = argv[:i], argv[i+1:]
+	}
+
+	fs := flag.NewFlagSet("howto", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.BoolVar(&opts.showVersion, "version", false, "print the version and exit")
+	fs.StringVar(&opts.profile, "profile", "", "project profile to apply (defaults to $"+app.EnvProfile+")")
+	fs.BoolVar(&opts.strict, "strict", false, "fail when the playbook libraries have problems")
+	fs.StringVar(&opts.format, "format", "", "output format: text, json or xml for playbooks, "+strings.Join(graph.Formats, ", ")+" for graph, "+strings.Join(stale.Formats, ", ")+" for stale")
+	fs.IntVar(&opts.maxTokens, "max-tokens", 0, "trim fetched playbooks to at most this many estimated tokens, leaving out whole sections")
+	app.RegisterPathFlags(fs, &opts.paths)
+
+	var positional []string
+	for {
+		if err := fs.Parse(argv); err != nil {
+			return opts, nil, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		argv = fs.Args()[1:]
+	}
+
+	if names != nil {
+		opts.namesFrom = len(positional)
+		positional = append(positional, names...)
+	}
+	return opts, positional, nil
+}